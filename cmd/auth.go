@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/credentials"
+	"github.com/boycook/gitall/internal/github"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect forge credentials",
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which token source each account uses",
+	Long: `Resolve a token for every configured account and report where it came
+from. GitHub tokens are looked up in order: the account's token in the
+config, GITHUB_TOKEN, GH_TOKEN, the gh CLI's hosts.yml, and git credential
+fill for the API host. Other providers read the account's token or their
+own variables: GITLAB_TOKEN, GITEA_TOKEN, FORGEJO_TOKEN, or
+BITBUCKET_APP_PASSWORD and BITBUCKET_TOKEN. Token values are never printed.`,
+	RunE: runAuthStatus,
+}
+
+var authCheck bool
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authStatusCmd)
+
+	authStatusCmd.Flags().BoolVar(&authCheck, "check", false, "verify each token against the API and show the authenticated user")
+}
+
+type authStatus struct {
+	Account string             `json:"account"`
+	Host    string             `json:"host"`
+	Source  credentials.Source `json:"source"`
+	Token   credentials.Token  `json:"token"`
+	Login   string             `json:"login,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	var accounts []config.Account
+	if cfg, err := loadConfig(); err == nil {
+		accounts = cfg.Accounts
+	}
+	if len(accounts) == 0 {
+		accounts = []config.Account{{Username: "(default)"}}
+	}
+
+	statuses := make([]authStatus, len(accounts))
+	for i, account := range accounts {
+		host := github.WebHost(account.APIURL)
+		token := resolveHostToken(account.Token, host)
+		if p, err := newProvider(account); err == nil {
+			host = p.Host()
+			token = accountToken(account, p)
+		}
+		statuses[i] = authStatus{
+			Account: account.Username,
+			Host:    host,
+			Source:  token.Source,
+			Token:   token,
+		}
+
+		if authCheck && token.IsSet() && isGitHub(account.Provider) {
+			me, err := newGitHubClient(account.APIURL, account.Token).AuthenticatedUser()
+			if err != nil {
+				statuses[i].Error = err.Error()
+			} else {
+				statuses[i].Login = me.Login
+			}
+		}
+	}
+
+	if jsonOut {
+		data, _ := json.MarshalIndent(statuses, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tHOST\tSOURCE\tTOKEN\tLOGIN")
+	fmt.Fprintln(w, "-------\t----\t------\t-----\t-----")
+	for _, s := range statuses {
+		login := s.Login
+		if s.Error != "" {
+			login = color.RedString(s.Error)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Account, s.Host, s.Source, s.Token, login)
+	}
+	w.Flush()
+	return nil
+}
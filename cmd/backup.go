@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boycook/gitall/internal/backup"
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/boycook/gitall/internal/provider"
+	"github.com/boycook/gitall/internal/runner"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Keep mirror backups of every repo for configured accounts",
+	Long: `Mirror every repo of each active account into a backup directory as a
+bare repository at <dir>/<host>/<owner>/<name>.git. The first run makes a
+--mirror clone; later runs fetch every ref and prune deleted ones.
+
+The time of each repo's last successful backup is recorded in the backup
+directory. Backups older than --max-age are reported after each run; use
+--check to only report them, for example from a monitoring job.`,
+	RunE: runBackup,
+}
+
+var (
+	backupUser        string
+	backupDir         string
+	backupConcurrency int
+	backupWikis       bool
+	backupMaxAge      string
+	backupCheck       bool
+	backupNoForks     bool
+	backupDryRun      bool
+)
+
+// defaultBackupMaxAge is used when neither --max-age nor backup.max_age is set.
+const defaultBackupMaxAge = "7d"
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+
+	backupCmd.Flags().StringVar(&backupUser, "user", "", "only back up this configured account")
+	backupCmd.Flags().StringVar(&backupDir, "dir", "", "backup directory (default backup.dir from the config)")
+	backupCmd.Flags().IntVarP(&backupConcurrency, "concurrency", "j", 4, "number of concurrent backups")
+	backupCmd.Flags().BoolVar(&backupWikis, "wikis", false, "also mirror repo wikis")
+	backupCmd.Flags().StringVar(&backupMaxAge, "max-age", "", "report backups older than this, e.g. 36h or 7d (default 7d)")
+	backupCmd.Flags().BoolVar(&backupCheck, "check", false, "only report stale backups, failing if there are any")
+	backupCmd.Flags().BoolVar(&backupNoForks, "no-forks", false, "skip forked repos")
+	backupCmd.Flags().BoolVar(&backupDryRun, "dry-run", false, "show what would be backed up without touching the backup dir")
+}
+
+type backupJob struct {
+	Key  string // host/owner/name, with a .wiki suffix for wikis
+	Name string
+	URL  string
+	Dir  string
+	Wiki bool
+}
+
+type backupSettings struct {
+	Dir    string
+	MaxAge time.Duration
+	Wikis  bool
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	path := configPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("no config found at %s — run 'gitall config init' to create one", path)
+	}
+
+	settings, err := resolveBackupSettings(cfg)
+	if err != nil {
+		return err
+	}
+
+	state, err := backup.LoadState(settings.Dir)
+	if err != nil {
+		return err
+	}
+
+	if backupCheck {
+		return reportStaleBackups(state, settings.MaxAge)
+	}
+
+	accounts, err := selectAccounts(cfg, backupUser)
+	if err != nil {
+		return err
+	}
+	reportQuota(accounts)
+
+	var jobs []backupJob
+	for _, account := range accounts {
+		output.Infof(quiet, "Listing repos for %s...", account.Username)
+		p, repos, err := listAccountRepos(account)
+		if err != nil {
+			return fmt.Errorf("listing repos for %s: %w", account.Username, err)
+		}
+		repos = provider.FilterRepos(repos, provider.Filter{NoForks: backupNoForks})
+		jobs = append(jobs, buildBackupJobs(account, p, repos, settings)...)
+	}
+
+	if len(jobs) == 0 {
+		output.Infof(quiet, "No repos to back up.")
+		return nil
+	}
+
+	if backupDryRun {
+		items := make([]string, len(jobs))
+		for i, job := range jobs {
+			items[i] = fmt.Sprintf("%s -> %s", job.Name, job.Dir)
+		}
+		output.PrintDryRun(items, "back up")
+		return nil
+	}
+
+	output.Infof(quiet, "Backing up %d repos to %s...", len(jobs), settings.Dir)
+	results := mirrorRepos(jobs, backupConcurrency)
+
+	now := time.Now().UTC()
+	for i, result := range results {
+		switch result.Status {
+		case git.Success:
+			state.Record(jobs[i].Key, now, nil)
+		case git.Failed:
+			state.Record(jobs[i].Key, now, errors.New(result.Message))
+		}
+	}
+	if err := state.Save(settings.Dir); err != nil {
+		return err
+	}
+
+	output.PrintSummary(results, "Backup", jsonOut)
+
+	if stale := state.Stale(settings.MaxAge, now); len(stale) > 0 && !jsonOut {
+		printStaleBackups(stale, settings.MaxAge, now)
+	}
+	return nil
+}
+
+func resolveBackupSettings(cfg *config.Config) (backupSettings, error) {
+	dir := cfg.Backup.Dir
+	if backupDir != "" {
+		dir = backupDir
+	}
+	if dir == "" {
+		return backupSettings{}, fmt.Errorf("no backup directory — set backup.dir in the config or use --dir")
+	}
+
+	maxAgeSetting := defaultBackupMaxAge
+	if cfg.Backup.MaxAge != "" {
+		maxAgeSetting = cfg.Backup.MaxAge
+	}
+	if backupMaxAge != "" {
+		maxAgeSetting = backupMaxAge
+	}
+	maxAge, err := backup.ParseMaxAge(maxAgeSetting)
+	if err != nil {
+		return backupSettings{}, err
+	}
+
+	return backupSettings{
+		Dir:    dir,
+		MaxAge: maxAge,
+		Wikis:  cfg.Backup.Wikis || backupWikis,
+	}, nil
+}
+
+func buildBackupJobs(account config.Account, p provider.Provider, repos []provider.Repo, settings backupSettings) []backupJob {
+	wikis, _ := p.(provider.WikiProvider)
+
+	var jobs []backupJob
+	for _, repo := range repos {
+		cloneURL := p.CloneURL(repo, account.Protocol)
+		host := cloneHost(p, cloneURL)
+		owner := repo.ListedOwner(account.Username)
+		ownerDir := filepath.Join(settings.Dir, host, owner)
+		name := path.Join(owner, repo.Namespace, repo.Name)
+		key := host + "/" + name
+		jobs = append(jobs, backupJob{
+			Key:  key,
+			Name: name,
+			URL:  cloneURL,
+			Dir:  filepath.Join(ownerDir, repo.Namespace, repo.Name+".git"),
+		})
+		if settings.Wikis && wikis != nil && repo.HasWiki {
+			jobs = append(jobs, backupJob{
+				Key:  key + ".wiki",
+				Name: name + ".wiki",
+				URL:  wikis.WikiURL(repo, account.Protocol),
+				Dir:  filepath.Join(ownerDir, repo.Namespace, repo.Name+".wiki.git"),
+				Wiki: true,
+			})
+		}
+	}
+	return jobs
+}
+
+func mirrorRepos(jobs []backupJob, concurrency int) []git.RepoResult {
+	tasks := make([]runner.Task, len(jobs))
+	for i, job := range jobs {
+		j := job
+		tasks[i] = runner.Task{
+			Name: j.Name,
+			Execute: func() git.RepoResult {
+				result := git.Mirror(j.URL, j.Dir)
+				result.Name = j.Name
+				// GitHub reports a wiki as enabled before its first page
+				// exists, and serves no repo for it until then.
+				if j.Wiki && result.Status == git.Failed && strings.Contains(strings.ToLower(result.Message), "not found") {
+					result.Status = git.Skipped
+					result.Message = "wiki has no pages"
+				}
+				return result
+			},
+		}
+	}
+
+	return runner.RunWithProgress(tasks, concurrency, func(completed, total int, result git.RepoResult) {
+		output.Progress(completed, total, result, quiet)
+	})
+}
+
+// reportStaleBackups is the --check mode: it prints backups older than
+// maxAge and returns an error when there are any.
+func reportStaleBackups(state *backup.State, maxAge time.Duration) error {
+	now := time.Now().UTC()
+	stale := state.Stale(maxAge, now)
+
+	if jsonOut {
+		type staleJSON struct {
+			Repo        string     `json:"repo"`
+			LastSuccess *time.Time `json:"last_success"`
+			LastError   string     `json:"last_error,omitempty"`
+		}
+		report := make([]staleJSON, len(stale))
+		for i, s := range stale {
+			report[i] = staleJSON{Repo: s.Key, LastError: s.LastError}
+			if !s.LastSuccess.IsZero() {
+				report[i].LastSuccess = &stale[i].LastSuccess
+			}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]any{"stale": report})
+	} else if len(stale) == 0 {
+		output.Infof(quiet, "All %d backups are newer than %s.", len(state.Repos), backup.FormatMaxAge(maxAge))
+	} else {
+		printStaleBackups(stale, maxAge, now)
+	}
+
+	if len(stale) > 0 {
+		return fmt.Errorf("%d backup(s) older than %s", len(stale), backup.FormatMaxAge(maxAge))
+	}
+	return nil
+}
+
+func printStaleBackups(stale []backup.Stale, maxAge time.Duration, now time.Time) {
+	yellow := color.New(color.FgYellow)
+	yellow.Fprintf(os.Stderr, "\n%d backup(s) older than %s:\n", len(stale), backup.FormatMaxAge(maxAge))
+	for _, s := range stale {
+		line := fmt.Sprintf("  %s  last success: %s", s.Key, s.Age(now))
+		if s.LastError != "" {
+			line += "  (" + s.LastError + ")"
+		}
+		fmt.Fprintln(os.Stderr, line)
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/credentials"
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/github"
+	"github.com/boycook/gitall/internal/provider"
+	_ "github.com/boycook/gitall/internal/provider/all"
+)
+
+var (
+	tokenResolver  = credentials.NewResolver()
+	tokenMu        sync.Mutex
+	resolvedTokens = map[string]credentials.Token{}
+)
+
+// newGitHubClient builds an API client for an account, resolving its token
+// through the credential chain and applying the global cache and retry flags.
+// Commands that work with any forge use newProvider instead.
+func newGitHubClient(apiURL, configToken string) *github.Client {
+	apiURL = github.NormalizeAPIURL(apiURL)
+	client := github.NewClient(apiURL, resolveToken(configToken, apiURL).Value())
+	client.Configure(providerOptions())
+	return client
+}
+
+// providerOptions carries the global flags to providers.
+func providerOptions() provider.Options {
+	return provider.Options{
+		Offline: offline,
+		Refresh: refresh,
+		Retries: retries,
+		MaxWait: maxWait,
+		Credentials: func(configToken, host string) string {
+			return resolveHostToken(configToken, host).Value()
+		},
+	}
+}
+
+// newProvider builds the forge provider for a configured account.
+func newProvider(account config.Account) (provider.Provider, error) {
+	var params map[string]string
+	if account.Manifest != "" {
+		params = map[string]string{"manifest": account.Manifest}
+	}
+	return provider.New(account.Provider, provider.Account{
+		Owner:  account.Username,
+		APIURL: account.APIURL,
+		Token:  account.Token,
+		Mode:   account.Mode,
+		Team:   account.Team,
+		Params: params,
+	}, providerOptions())
+}
+
+// isGitHub reports whether a provider name selects the GitHub provider, for
+// the commands that use GitHub-only APIs.
+func isGitHub(providerName string) bool {
+	return providerName == "" || strings.EqualFold(providerName, "github")
+}
+
+// remoteProviders returns a provider for every configured account plus one
+// for each registered provider's public service, so remotes on any known
+// forge can be recognised.
+func remoteProviders(cfg *config.Config) []provider.Provider {
+	var providers []provider.Provider
+	for _, account := range cfg.Accounts {
+		if p, err := newProvider(account); err == nil {
+			providers = append(providers, p)
+		}
+	}
+	for _, name := range provider.Names() {
+		if p, err := provider.New(name, provider.Account{}, providerOptions()); err == nil {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// recogniseRemote returns the first provider that claims a remote URL.
+func recogniseRemote(providers []provider.Provider, remoteURL string) (provider.Provider, git.Remote, bool) {
+	for _, p := range providers {
+		if remote, ok := p.ParseRemote(remoteURL); ok {
+			return p, remote, true
+		}
+	}
+	return nil, git.Remote{}, false
+}
+
+// apiURLForHost returns an API base URL for a git host, or "" for
+// github.com. Providers expand it to their API endpoint.
+func apiURLForHost(host string) string {
+	if host == "" || host == "github.com" {
+		return ""
+	}
+	return "https://" + host
+}
+
+// repoHost returns the host to record on a config repo entry; github.com is
+// the default and is left blank.
+func repoHost(host string) string {
+	if host == "github.com" {
+		return ""
+	}
+	return host
+}
+
+// repoProvider returns the provider name to record on a config repo entry;
+// github is the default and is left blank.
+func repoProvider(name string) string {
+	if isGitHub(name) {
+		return ""
+	}
+	return name
+}
+
+// accountToken returns the token an account's provider authenticates with.
+// GitHub tokens come from the shared credential chain; other providers
+// report their own, and providers without one have none.
+func accountToken(account config.Account, p provider.Provider) credentials.Token {
+	if cp, ok := p.(provider.CredentialProvider); ok {
+		value, source := cp.Credential()
+		return credentials.NewToken(value, credentials.Source(source))
+	}
+	if isGitHub(p.Name()) {
+		return resolveHostToken(account.Token, p.Host())
+	}
+	return credentials.NewToken("", "")
+}
+
+// resolveToken looks up the token for a GitHub API URL.
+func resolveToken(configToken, apiURL string) credentials.Token {
+	return resolveHostToken(configToken, github.WebHost(apiURL))
+}
+
+// resolveHostToken memoises credential lookups, since the gh and git
+// credential fallbacks read files or run git for every call.
+func resolveHostToken(configToken, host string) credentials.Token {
+	key := configToken + "\x00" + host
+
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+
+	if token, ok := resolvedTokens[key]; ok {
+		return token
+	}
+	token := tokenResolver.Resolve(configToken, host)
+	resolvedTokens[key] = token
+	return token
+}
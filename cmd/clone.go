@@ -0,0 +1,613 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/layout"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/boycook/gitall/internal/provider"
+	"github.com/boycook/gitall/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "Clone all repositories for configured accounts",
+	Long: `Clone every repository for a GitHub user or organisation into a local
+directory. Repos that already exist locally are skipped. By default every
+active account in the config is cloned into its dir. Use --user and --dir
+to clone an account that is not in the config; repos cloned that way are
+recorded in the config so that pull, fetch and status pick them up.`,
+	RunE: runClone,
+}
+
+var (
+	cloneUser        string
+	cloneDir         string
+	cloneProtocol    string
+	cloneNoForks     bool
+	cloneNoArchived  bool
+	cloneFilter      string
+	cloneDryRun      bool
+	cloneConcurrency int
+	cloneMode        string
+	cloneTeam        string
+	cloneType        string
+	cloneAffiliation string
+	cloneFilters     repoFilters
+	cloneTags        tagFlags
+	cloneSettings    cloneSettingFlags
+	cloneAPIURL      string
+	cloneProvider    string
+	cloneManifest    string
+)
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+
+	cloneCmd.Flags().StringVar(&cloneUser, "user", "", "GitHub user or organisation to clone")
+	cloneCmd.Flags().StringVar(&cloneDir, "dir", "", "directory to clone into (defaults to the current directory)")
+	cloneCmd.Flags().StringVar(&cloneProtocol, "protocol", "ssh", "clone protocol (ssh or https)")
+	cloneCmd.Flags().BoolVar(&cloneNoForks, "no-forks", false, "exclude forked repos")
+	cloneCmd.Flags().BoolVar(&cloneNoArchived, "no-archived", false, "exclude archived repos")
+	cloneCmd.Flags().StringVar(&cloneFilter, "filter", "", "only clone repos matching this glob pattern (e.g. \"api-*\")")
+	cloneCmd.Flags().BoolVar(&cloneDryRun, "dry-run", false, "show what would be cloned without cloning")
+	cloneCmd.Flags().IntVarP(&cloneConcurrency, "concurrency", "j", 4, "number of concurrent clones")
+	cloneCmd.Flags().StringVar(&cloneMode, "mode", "auto", "listing mode: auto, user, org, team or authenticated")
+	cloneCmd.Flags().StringVar(&cloneTeam, "team", "", "team slug to list repos for (implies --mode team)")
+	cloneCmd.Flags().StringVar(&cloneType, "type", "", "org repo type: all, public, private, forks, sources or member")
+	cloneCmd.Flags().StringVar(&cloneAffiliation, "affiliation", "", "authenticated user affiliation: owner, collaborator, organization_member")
+	cloneCmd.Flags().StringVar(&cloneAPIURL, "api-url", "", "API URL for a self-hosted forge (e.g. https://ghe.example.com/api/v3)")
+	cloneCmd.Flags().StringVar(&cloneProvider, "provider", "", "forge the --user account is on (default github)")
+	cloneCmd.Flags().StringVar(&cloneManifest, "manifest", "", "clone the repos listed in this manifest file or URL")
+	cloneFilters.register(cloneCmd)
+	cloneTags.register(cloneCmd)
+	cloneSettings.register(cloneCmd)
+}
+
+type cloneAccount struct {
+	Username string
+	Dir      string
+	Provider string
+	Protocol string
+	Token    string
+	APIURL   string
+	Mode     string
+	Team     string
+	Manifest string
+	Clone    config.CloneSettings
+	Layout   layout.Layout
+	// RepoClone holds per-repo clone settings from the config, keyed by
+	// lowercase repo name. They override Clone.
+	RepoClone map[string]config.CloneSettings
+	// Tags come from the account entry and are shared by all its repos.
+	// RepoTags adds those of repo entries, keyed by lowercase repo name.
+	Tags     []string
+	RepoTags map[string][]string
+	// Configured accounts rediscover their checkouts from Dir, so their
+	// clones do not need individual repo entries in the config.
+	Configured bool
+}
+
+type cloneJob struct {
+	Repo     config.Repo
+	CloneURL string
+	Options  git.CloneOptions
+	Record   bool
+}
+
+// cloneSettingFlags are the git clone flags shared by clone and sync. They
+// override the clone settings of accounts and repos in the config.
+type cloneSettingFlags struct {
+	config.CloneSettings
+}
+
+func (f *cloneSettingFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&f.Depth, "depth", 0, "shallow clone with this many commits of history")
+	cmd.Flags().StringVar(&f.Filter, "partial", "", "partial clone filter: blob:none or tree:0")
+	cmd.Flags().BoolVar(&f.SingleBranch, "single-branch", false, "clone only one branch")
+	cmd.Flags().StringVar(&f.Branch, "branch", "", "branch to check out (and to clone with --single-branch)")
+	cmd.Flags().BoolVar(&f.RecurseSubmodules, "recurse-submodules", false, "also clone submodules")
+	cmd.Flags().StringVar(&f.Origin, "origin", "", "name for the remote instead of origin")
+}
+
+func (f *cloneSettingFlags) settings() (config.CloneSettings, error) {
+	if err := f.CloneSettings.Validate(); err != nil {
+		return config.CloneSettings{}, err
+	}
+	return f.CloneSettings, nil
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	if cloneProtocol != "ssh" && cloneProtocol != "https" {
+		return fmt.Errorf("invalid protocol %q (must be ssh or https)", cloneProtocol)
+	}
+
+	overrides, err := cloneSettings.settings()
+	if err != nil {
+		return err
+	}
+
+	filter, err := cloneRepoFilter()
+	if err != nil {
+		return err
+	}
+
+	sel, err := cloneTags.selector()
+	if err != nil {
+		return err
+	}
+
+	accounts, err := resolveCloneAccounts(cmd)
+	if err != nil {
+		return err
+	}
+
+	var jobs []cloneJob
+	for _, account := range accounts {
+		p, err := account.buildProvider()
+		if err != nil {
+			return fmt.Errorf("%s: %w", account.Username, err)
+		}
+
+		output.Infof(quiet, "Listing repos for %s...", account.Username)
+		repos, err := p.ListRepos(filter)
+		if err != nil {
+			return fmt.Errorf("listing repos for %s: %w", account.Username, err)
+		}
+		repos = account.selectTagged(repos, sel)
+		accountJobs, err := buildCloneJobs(account, p, repos, overrides)
+		if err != nil {
+			return fmt.Errorf("%s: %w", account.Username, err)
+		}
+		jobs = append(jobs, accountJobs...)
+	}
+
+	if len(jobs) == 0 {
+		output.Infof(quiet, "No repos to clone.")
+		return nil
+	}
+
+	jobs, collided := splitCollisions(jobs, configuredRepos())
+
+	if cloneDryRun {
+		items := make([]string, 0, len(jobs)+len(collided))
+		for _, job := range jobs {
+			items = append(items, fmt.Sprintf("%s/%s -> %s", job.Repo.Owner, job.Repo.Name, job.Repo.Dir))
+		}
+		for _, result := range collided {
+			items = append(items, fmt.Sprintf("%s: %s", result.Name, result.Message))
+		}
+		output.PrintDryRun(items, "clone")
+		return nil
+	}
+
+	for i, result := range collided {
+		output.Progress(i+1, len(collided), result, quiet)
+	}
+
+	output.Infof(quiet, "Cloning %d repos...", len(jobs))
+	results := cloneRepos(jobs, cloneConcurrency)
+	recordErr := recordClonedRepos(jobs, results)
+	output.PrintSummary(append(collided, results...), "Clone", jsonOut)
+	return recordErr
+}
+
+func cloneRepoFilter() (provider.Filter, error) {
+	filter := provider.Filter{
+		NoForks:    cloneNoForks,
+		NoArchived: cloneNoArchived,
+		Pattern:    cloneFilter,
+	}
+	if err := cloneFilters.apply(&filter); err != nil {
+		return provider.Filter{}, err
+	}
+	return filter, nil
+}
+
+// buildProvider builds the account's forge provider. --type and
+// --affiliation are passed through as GitHub listing parameters.
+func (a cloneAccount) buildProvider() (provider.Provider, error) {
+	params := map[string]string{}
+	if cloneType != "" {
+		params["type"] = cloneType
+	}
+	if cloneAffiliation != "" {
+		params["affiliation"] = cloneAffiliation
+	}
+	if a.Manifest != "" {
+		params["manifest"] = a.Manifest
+	}
+
+	return provider.New(a.Provider, provider.Account{
+		Owner:  a.Username,
+		APIURL: a.APIURL,
+		Token:  a.Token,
+		Mode:   a.Mode,
+		Team:   a.Team,
+		Params: params,
+	}, providerOptions())
+}
+
+func resolveCloneAccounts(cmd *cobra.Command) ([]cloneAccount, error) {
+	cfg, cfgErr := loadConfig()
+
+	user := cloneUser
+	if user == "" && cloneManifest != "" {
+		user = manifestLabel(cloneManifest)
+	}
+
+	if user != "" {
+		account := cloneAccount{
+			Username: user,
+			Provider: cloneProvider,
+			Protocol: cloneProtocol,
+			APIURL:   cloneAPIURL,
+			Mode:     cloneMode,
+			Team:     cloneTeam,
+			Manifest: cloneManifest,
+		}
+		if cloneManifest != "" {
+			account.Provider = "manifest"
+		}
+		if cfgErr == nil {
+			if configured := cfg.FindAccount(user); configured != nil {
+				account = fromConfigAccount(*configured)
+				account.Configured = cloneDir == ""
+				applyCloneFlagOverrides(cmd, &account)
+			}
+			account.RepoClone = repoCloneSettings(cfg, user)
+			account.RepoTags = repoTags(cfg, user)
+			account.Layout = cfg.Layout
+		}
+
+		if cloneDir != "" {
+			account.Dir = cloneDir
+		}
+		if account.Dir == "" {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("resolving current directory: %w", err)
+			}
+			account.Dir = wd
+		}
+		return []cloneAccount{account}, nil
+	}
+
+	if errors.Is(cfgErr, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no config found.\nRun 'gitall config init' to create one, or use --user and --dir to clone an account")
+	}
+	if cfgErr != nil {
+		return nil, cfgErr
+	}
+
+	var accounts []cloneAccount
+	for _, configured := range cfg.Accounts {
+		if !configured.IsActive() {
+			output.Infof(quiet, "Skipping inactive account %s", configured.Username)
+			continue
+		}
+		account := fromConfigAccount(configured)
+		account.RepoClone = repoCloneSettings(cfg, configured.Username)
+		account.RepoTags = repoTags(cfg, configured.Username)
+		account.Layout = cfg.Layout
+		account.Configured = cloneDir == ""
+		if cloneDir != "" {
+			account.Dir = filepath.Join(cloneDir, account.Username)
+		}
+		applyCloneFlagOverrides(cmd, &account)
+		accounts = append(accounts, account)
+	}
+	accounts = append(accounts, accountsFromRepos(cfg)...)
+
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no active accounts found in config — use --user to clone an account")
+	}
+	return accounts, nil
+}
+
+func fromConfigAccount(account config.Account) cloneAccount {
+	return cloneAccount{
+		Username: account.Username,
+		Dir:      account.Dir,
+		Provider: account.Provider,
+		Protocol: account.Protocol,
+		Token:    account.Token,
+		APIURL:   account.APIURL,
+		Mode:     account.Mode,
+		Team:     account.Team,
+		Manifest: account.Manifest,
+		Clone:    account.Clone,
+		Tags:     account.Tags,
+	}
+}
+
+// manifestLabel names an ad-hoc manifest account after its file, e.g.
+// "workspace" for https://example.com/team/workspace.yaml.
+func manifestLabel(source string) string {
+	base := path.Base(filepath.ToSlash(source))
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// repoCloneSettings collects the clone settings of the config's repo entries
+// for one owner.
+func repoCloneSettings(cfg *config.Config, owner string) map[string]config.CloneSettings {
+	settings := map[string]config.CloneSettings{}
+	for _, repo := range cfg.Repos {
+		if strings.EqualFold(repo.Owner, owner) && repo.Clone != (config.CloneSettings{}) {
+			settings[strings.ToLower(repo.Name)] = repo.Clone
+		}
+	}
+	return settings
+}
+
+// repoTags collects the tags of the config's repo entries for one owner.
+func repoTags(cfg *config.Config, owner string) map[string][]string {
+	tags := map[string][]string{}
+	for _, repo := range cfg.Repos {
+		if strings.EqualFold(repo.Owner, owner) && len(repo.Tags) > 0 {
+			tags[strings.ToLower(repo.Name)] = repo.Tags
+		}
+	}
+	return tags
+}
+
+// selectTagged keeps the listed repos whose tags, the account's together
+// with those of a matching repo entry, are picked by sel.
+func (a cloneAccount) selectTagged(repos []provider.Repo, sel config.Selector) []provider.Repo {
+	if sel.IsEmpty() {
+		return repos
+	}
+	var selected []provider.Repo
+	for _, repo := range repos {
+		tags := append(slices.Clone(a.Tags), a.RepoTags[strings.ToLower(repo.Name)]...)
+		if sel.Matches(tags) {
+			selected = append(selected, repo)
+		}
+	}
+	return selected
+}
+
+// applyCloneFlagOverrides lets explicitly set flags take precedence over the
+// values stored on a configured account.
+func applyCloneFlagOverrides(cmd *cobra.Command, account *cloneAccount) {
+	if cmd.Flags().Changed("protocol") {
+		account.Protocol = cloneProtocol
+	}
+	if cmd.Flags().Changed("mode") {
+		account.Mode = cloneMode
+	}
+	if cmd.Flags().Changed("team") {
+		account.Team = cloneTeam
+	}
+	if cmd.Flags().Changed("api-url") {
+		account.APIURL = cloneAPIURL
+	}
+	if cmd.Flags().Changed("provider") {
+		account.Provider = cloneProvider
+	}
+	if cmd.Flags().Changed("manifest") {
+		account.Provider = "manifest"
+		account.Manifest = cloneManifest
+	}
+}
+
+// accountsFromRepos derives one clone account per repo owner that has no
+// account entry, using the parent directory and protocol of the first
+// configured repo for that owner.
+func accountsFromRepos(cfg *config.Config) []cloneAccount {
+	var accounts []cloneAccount
+	seen := map[string]bool{}
+
+	for _, repo := range cfg.Repos {
+		key := strings.ToLower(repo.Owner)
+		if repo.Owner == "" || seen[key] || cfg.FindAccount(repo.Owner) != nil {
+			continue
+		}
+		seen[key] = true
+
+		dir := filepath.Dir(repo.Dir)
+		if cloneDir != "" {
+			dir = filepath.Join(cloneDir, repo.Owner)
+		}
+		accounts = append(accounts, cloneAccount{
+			Username:  repo.Owner,
+			Dir:       dir,
+			Provider:  repo.Provider,
+			Protocol:  repo.Protocol,
+			APIURL:    apiURLForHost(repo.Host),
+			Mode:      cloneMode,
+			RepoClone: repoCloneSettings(cfg, repo.Owner),
+			RepoTags:  repoTags(cfg, repo.Owner),
+			Layout:    cfg.Layout,
+		})
+	}
+	return accounts
+}
+
+func buildCloneJobs(account cloneAccount, p provider.Provider, repos []provider.Repo, overrides config.CloneSettings) ([]cloneJob, error) {
+	jobs := make([]cloneJob, len(repos))
+	for i, repo := range repos {
+		cloneURL := p.CloneURL(repo, account.Protocol)
+		host := cloneHost(p, cloneURL)
+		owner := repo.ListedOwner(account.Username)
+
+		dir := filepath.Join(account.Dir, filepath.FromSlash(repo.Path))
+		if repo.Path == "" {
+			var err error
+			dir, err = account.Layout.Path(layout.Vars{
+				Dir:       account.Dir,
+				Host:      host,
+				Owner:     owner,
+				Name:      repo.Name,
+				Topics:    repo.Topics,
+				Namespace: repo.Namespace,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Repos in a subgroup record the full group path as their owner,
+		// matching what their remote URL reports.
+		if repo.Namespace != "" {
+			owner += "/" + repo.Namespace
+		}
+
+		// A branch chosen by the repo source ranks with the account's
+		// settings, below the repo's own config and the flags.
+		settings := account.Clone
+		if repo.Branch != "" {
+			settings = settings.Merge(config.CloneSettings{Branch: repo.Branch})
+		}
+		settings = settings.Merge(account.RepoClone[strings.ToLower(repo.Name)]).Merge(overrides)
+
+		jobs[i] = cloneJob{
+			Repo: config.Repo{
+				ID:       repo.ID,
+				Name:     repo.Name,
+				Owner:    owner,
+				Provider: repoProvider(p.Name()),
+				Host:     repoHost(host),
+				Dir:      dir,
+				Protocol: account.Protocol,
+			},
+			CloneURL: cloneURL,
+			Options:  gitCloneOptions(settings),
+			Record:   !account.Configured,
+		}
+	}
+	return jobs, nil
+}
+
+// cloneHost returns the provider's host, or the host of the clone URL for
+// providers whose repos live on different hosts.
+func cloneHost(p provider.Provider, cloneURL string) string {
+	if host := p.Host(); host != "" {
+		return host
+	}
+	remote, _ := git.ParseRemoteURL(cloneURL)
+	return remote.Host
+}
+
+// splitCollisions holds back jobs whose target dir is also claimed by a
+// different repo: another job, a config entry, or an existing checkout of
+// another repo. Cloning either would otherwise silently skip one of them.
+func splitCollisions(jobs []cloneJob, existing []config.Repo) ([]cloneJob, []git.RepoResult) {
+	var targets []layout.Target
+	for _, repo := range existing {
+		targets = append(targets, layout.Target{Repo: repo.Owner + "/" + repo.Name, Path: repo.Dir})
+	}
+	for _, job := range jobs {
+		targets = append(targets, layout.Target{Repo: job.Repo.Owner + "/" + job.Repo.Name, Path: job.Repo.Dir})
+		if _, err := os.Stat(job.Repo.Dir); err != nil {
+			continue
+		}
+		if remote, ok := git.ParseRemoteURL(git.RemoteURL(job.Repo.Dir)); ok {
+			targets = append(targets, layout.Target{Repo: remote.FullName(), Path: job.Repo.Dir})
+		}
+	}
+
+	collisions := layout.Collisions(targets)
+	if len(collisions) == 0 {
+		return jobs, nil
+	}
+
+	byPath := map[string]layout.Collision{}
+	for _, collision := range collisions {
+		byPath[strings.ToLower(collision.Path)] = collision
+	}
+
+	var clean []cloneJob
+	var collided []git.RepoResult
+	for _, job := range jobs {
+		collision, ok := byPath[strings.ToLower(job.Repo.Dir)]
+		if !ok {
+			clean = append(clean, job)
+			continue
+		}
+		collided = append(collided, git.RepoResult{
+			Name:    job.Repo.Name,
+			Path:    job.Repo.Dir,
+			Status:  git.Failed,
+			Message: "name collision: " + collision.String(),
+		})
+	}
+	return clean, collided
+}
+
+// configuredRepos returns the repo entries of the config, if there is one.
+func configuredRepos() []config.Repo {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	return cfg.Repos
+}
+
+func gitCloneOptions(settings config.CloneSettings) git.CloneOptions {
+	return git.CloneOptions{
+		Depth:             settings.Depth,
+		Filter:            settings.Filter,
+		SingleBranch:      settings.SingleBranch,
+		Branch:            settings.Branch,
+		RecurseSubmodules: settings.RecurseSubmodules,
+		Origin:            settings.Origin,
+	}
+}
+
+func cloneRepos(jobs []cloneJob, concurrency int) []git.RepoResult {
+	tasks := make([]runner.Task, len(jobs))
+	for i, job := range jobs {
+		j := job
+		tasks[i] = runner.Task{
+			Name: j.Repo.Name,
+			Execute: func() git.RepoResult {
+				return git.CloneWithOptions(j.CloneURL, j.Repo.Dir, j.Options)
+			},
+		}
+	}
+
+	return runner.RunWithProgress(tasks, concurrency, func(completed, total int, result git.RepoResult) {
+		output.Progress(completed, total, result, quiet)
+	})
+}
+
+// recordClonedRepos adds the cloned repos to the config file. A file that
+// exists but does not load is left as it is rather than replaced.
+func recordClonedRepos(jobs []cloneJob, results []git.RepoResult) error {
+	path := configPath()
+	cfg, err := loadConfigForEdit()
+	if err != nil {
+		return fmt.Errorf("not recording cloned repos in %s: %w", path, err)
+	}
+
+	added := 0
+	for i, result := range results {
+		if result.Status != git.Success || !jobs[i].Record {
+			continue
+		}
+		if err := cfg.AddRepo(jobs[i].Repo); err == nil {
+			added++
+		}
+	}
+
+	if added == 0 {
+		return nil
+	}
+
+	if err := config.Save(cfg, path); err != nil {
+		return fmt.Errorf("recording cloned repos: %w", err)
+	}
+	output.Infof(quiet, "Added %d repo(s) to %s", added, path)
+	return nil
+}
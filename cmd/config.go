@@ -1,13 +1,19 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/boycook/gitall/internal/config"
 	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/layout"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/boycook/gitall/internal/provider"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -20,7 +26,11 @@ var configCmd = &cobra.Command{
 var configListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Display current configuration",
-	RunE:  runConfigList,
+	Long: `Display the accounts and repos of the merged configuration. The system
+file, the user file and a .gitall.yaml found in the current directory or
+above are merged in that order, later files taking precedence. Use
+--show-origin to see which file each entry comes from.`,
+	RunE: runConfigList,
 }
 
 var configInitCmd = &cobra.Command{
@@ -29,6 +39,31 @@ var configInitCmd = &cobra.Command{
 	RunE:  runConfigInit,
 }
 
+var configAddCmd = &cobra.Command{
+	Use:   "add [path]",
+	Short: "Add a checkout or an account to the config",
+	Long: `Register an existing checkout, reading its owner, host and protocol
+from its origin remote, or add a whole account with --username and --dir.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigAdd,
+}
+
+var configRemoveCmd = &cobra.Command{
+	Use:   "remove <name|owner|path>...",
+	Short: "Remove accounts or repos from the config",
+	Long: `Remove the accounts and repos each argument matches: an account by
+username, and repos by name, owner/name, owner or checkout path. Relative
+paths must start with . (use ./api rather than api).
+
+With --delete, the checkouts of removed repos and accounts are deleted too,
+except those with uncommitted changes, untracked files, stashes, commits
+no remote has or tags the remote lacks. --force deletes those as well.
+Ignored files, such as a local .env, are deleted with the checkout and
+listed as a warning; use --dry-run to preview.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runConfigRemove,
+}
+
 var configPruneCmd = &cobra.Command{
 	Use:   "prune",
 	Short: "Remove repos whose directories no longer exist",
@@ -40,12 +75,44 @@ var configDiscoverCmd = &cobra.Command{
 	Short: "Auto-generate config by scanning a directory for existing repos",
 	Long: `Recursively scan a directory for git repositories, group them by
 GitHub owner (from remote URL), and generate config entries automatically.
-Use --dry-run to preview without writing.`,
+Checkouts that are not where the configured layout would put them are
+flagged. Use --dry-run to preview without writing.`,
 	RunE: runConfigDiscover,
 }
 
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the config file to the current version",
+	Long: `Upgrade ~/.gitall/config.yaml to the current config version, or convert
+the legacy ~/.gitall/config.json of the original gitall into it. The
+original file is backed up first and the changes are shown as a diff.
+Use --dry-run to see the diff without writing.`,
+	RunE: runConfigMigrate,
+}
+
+var (
+	addUsername string
+	addDir      string
+	addProvider string
+	addProtocol string
+	addAPIURL   string
+)
+
+var (
+	removeDryRun bool
+	removeDelete bool
+	removeForce  bool
+)
+
+var (
+	configListTags tagFlags
+	listShowOrigin bool
+)
+
 var pruneDryRun bool
 
+var migrateDryRun bool
+
 var (
 	discoverDir    string
 	discoverDryRun bool
@@ -55,44 +122,122 @@ func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configListCmd)
 	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configAddCmd)
+	configCmd.AddCommand(configRemoveCmd)
 	configCmd.AddCommand(configPruneCmd)
 	configCmd.AddCommand(configDiscoverCmd)
+	configCmd.AddCommand(configMigrateCmd)
+
+	configListTags.register(configListCmd)
+	configListCmd.Flags().BoolVar(&listShowOrigin, "show-origin", false, "show which config file each account and repo comes from")
+
+	configAddCmd.Flags().StringVar(&addUsername, "username", "", "add an account for this user, org or group")
+	configAddCmd.Flags().StringVar(&addDir, "dir", "", "directory for the account's repos")
+	configAddCmd.Flags().StringVar(&addProvider, "provider", "", "forge the account is on (default github)")
+	configAddCmd.Flags().StringVar(&addProtocol, "protocol", "ssh", "clone protocol for the account (ssh or https)")
+	configAddCmd.Flags().StringVar(&addAPIURL, "api-url", "", "API base URL for self-hosted forges")
+
+	configRemoveCmd.Flags().BoolVar(&removeDryRun, "dry-run", false, "preview what would be removed without writing config")
+	configRemoveCmd.Flags().BoolVar(&removeDelete, "delete", false, "also delete checkouts that have no unpushed work")
+	configRemoveCmd.Flags().BoolVar(&removeForce, "force", false, "with --delete, also delete checkouts that have unpushed work")
 
 	configPruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "preview what would be removed without writing config")
 
+	configMigrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "show the changes without writing config")
+
 	configDiscoverCmd.Flags().StringVar(&discoverDir, "dir", "", "directory to scan recursively (required)")
 	configDiscoverCmd.Flags().BoolVar(&discoverDryRun, "dry-run", false, "preview discovered repos without writing config")
 	configDiscoverCmd.MarkFlagRequired("dir")
 }
 
 func runConfigList(cmd *cobra.Command, args []string) error {
-	path := config.DefaultPath()
-	cfg, err := config.Load(path)
+	sel, err := configListTags.selector()
 	if err != nil {
-		return fmt.Errorf("no config found at %s — run 'gitall config init' to create one", path)
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no config found at %s — run 'gitall config init' to create one", configPath())
+	}
+	if err != nil {
+		return err
 	}
 
+	for _, source := range cfg.Sources() {
+		color.New(color.Bold).Fprintf(os.Stdout, "Config: %s (%s)\n", source.Path, describeScope(source))
+	}
+	fmt.Println()
+
+	printConfigEntries(cfg, sel, listShowOrigin)
+	return nil
+}
+
+// describeScope names where a config file sits in the layers, including the
+// profile read from it.
+func describeScope(source config.Source) string {
+	if source.Profile != "" {
+		return fmt.Sprintf("%s, profile %s", source.Scope, source.Profile)
+	}
+	return source.Scope
+}
+
+// printConfigEntries prints the accounts and repos of cfg that sel picks,
+// with the file each came from if showOrigin is set.
+func printConfigEntries(cfg *config.Config, sel config.Selector, showOrigin bool) {
 	bold := color.New(color.Bold)
 
-	bold.Fprintf(os.Stdout, "Config: %s\n\n", path)
+	// originColumn adds the ORIGIN column for --show-origin.
+	originColumn := func(value string) string {
+		if !showOrigin {
+			return ""
+		}
+		return "\t" + value
+	}
+
+	if cfg.HasAccounts() {
+		bold.Fprintln(os.Stdout, "Accounts:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "USERNAME\tDIR\tPROTOCOL\tACTIVE\tTAGS"+originColumn("ORIGIN"))
+		fmt.Fprintln(w, "--------\t---\t--------\t------\t----"+originColumn("------"))
+
+		for _, account := range cfg.Accounts {
+			if !sel.Matches(account.Tags) {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%s%s\n",
+				account.Username, account.Dir, account.Protocol, account.IsActive(), strings.Join(account.Tags, ","),
+				originColumn(account.Origin()))
+		}
+
+		w.Flush()
+
+		if !cfg.HasRepos() {
+			return
+		}
+		fmt.Println()
+	}
 
 	bold.Fprintln(os.Stdout, "Repos:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tOWNER\tDIR\tPROTOCOL")
-	fmt.Fprintln(w, "----\t-----\t---\t--------")
+	fmt.Fprintln(w, "NAME\tOWNER\tDIR\tPROTOCOL\tTAGS"+originColumn("ORIGIN"))
+	fmt.Fprintln(w, "----\t-----\t---\t--------\t----"+originColumn("------"))
 
 	for _, repo := range cfg.Repos {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-			repo.Name, repo.Owner, repo.Dir, repo.Protocol)
+		tags := cfg.RepoTags(repo)
+		if !sel.Matches(tags) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s%s\n",
+			repo.Name, repo.Owner, repo.Dir, repo.Protocol, strings.Join(tags, ","),
+			originColumn(repo.Origin()))
 	}
 
 	w.Flush()
-
-	return nil
 }
 
 func runConfigInit(cmd *cobra.Command, args []string) error {
-	path := config.DefaultPath()
+	path := configPath()
 
 	if _, err := os.Stat(path); err == nil {
 		return fmt.Errorf("config already exists at %s — edit it directly or delete it first", path)
@@ -104,13 +249,240 @@ func runConfigInit(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("Created default config at %s\n", path)
-	fmt.Println("Edit it to add your repositories.")
+	fmt.Println("Edit it to add your accounts and repositories.")
+	return nil
+}
+
+func runConfigAdd(cmd *cobra.Command, args []string) error {
+	if (len(args) == 1) == (addUsername != "") {
+		return fmt.Errorf("give either a checkout path or --username with --dir")
+	}
+
+	path := configPath()
+	cfg, err := loadConfigForEdit()
+	if err != nil {
+		return err
+	}
+
+	if addUsername != "" {
+		if addDir == "" {
+			return fmt.Errorf("--dir is required with --username")
+		}
+		dir, err := filepath.Abs(addDir)
+		if err != nil {
+			return err
+		}
+		account := config.Account{
+			Username: addUsername,
+			Dir:      dir,
+			Provider: repoProvider(addProvider),
+			Protocol: addProtocol,
+			APIURL:   addAPIURL,
+		}
+		if _, err := newProvider(account); err != nil {
+			return err
+		}
+		if err := cfg.AddAccount(account); err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		if err := config.Save(cfg, path); err != nil {
+			return err
+		}
+		fmt.Printf("Added account %s (%s) to %s\n", account.Username, account.Dir, path)
+		return nil
+	}
+
+	repoPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(repoPath); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", repoPath)
+	}
+	repo, ok := checkoutRepo(remoteProviders(cfg), repoPath)
+	if !ok {
+		return fmt.Errorf("%s has no origin remote gitall recognises", repoPath)
+	}
+	if err := cfg.AddRepo(repo); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := config.Save(cfg, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %s/%s (%s) to %s\n", repo.Owner, repo.Name, repo.Protocol, path)
+	return nil
+}
+
+// loadConfigForEdit loads the config to add entries to, starting an empty
+// one if there is no config file yet.
+func loadConfigForEdit() (*config.Config, error) {
+	cfg, err := loadConfigFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		return &config.Config{}, nil
+	}
+	return cfg, err
+}
+
+func runConfigRemove(cmd *cobra.Command, args []string) error {
+	path := configPath()
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return fmt.Errorf("no config found at %s — run 'gitall config init' to create one", path)
+	}
+
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+
+	var accounts []config.Account
+	var repos []config.Repo
+	for _, target := range args {
+		if strings.HasPrefix(target, ".") {
+			if target, err = filepath.Abs(target); err != nil {
+				return err
+			}
+		}
+		removedAccounts, removedRepos := cfg.RemoveMatching(target)
+		if len(removedAccounts) == 0 && len(removedRepos) == 0 {
+			yellow.Printf("  no match: %s\n", target)
+			continue
+		}
+		accounts = append(accounts, removedAccounts...)
+		repos = append(repos, removedRepos...)
+	}
+
+	if len(accounts) == 0 && len(repos) == 0 {
+		return fmt.Errorf("nothing in %s matches %s", path, strings.Join(args, ", "))
+	}
+
+	for _, account := range accounts {
+		red.Printf("  removed account: %s", account.Username)
+		fmt.Printf("  %s\n", account.Dir)
+	}
+	for _, repo := range repos {
+		red.Printf("  removed: %s", repo.Name)
+		fmt.Printf("  %s\n", repo.Dir)
+	}
+
+	if !removeDryRun {
+		if err := config.Save(cfg, path); err != nil {
+			return err
+		}
+	}
+
+	deleted := 0
+	if removeDelete {
+		fmt.Println()
+		deleted = deleteCheckouts(cfg, accounts, repos)
+	}
+
+	if removeDryRun {
+		fmt.Printf("\nDry run — would remove %d account(s) and %d repo(s).\n", len(accounts), len(repos))
+		return nil
+	}
+
+	fmt.Printf("\nRemoved %d account(s) and %d repo(s) from %s\n", len(accounts), len(repos), path)
+	if removeDelete {
+		fmt.Printf("Deleted %d checkout(s)\n", deleted)
+	}
 	return nil
 }
 
+// deleteCheckouts deletes the checkouts of removed repos and accounts that
+// hold no work missing from their remotes, and returns how many it deleted
+// (or would delete, in a dry run).
+func deleteCheckouts(cfg *config.Config, accounts []config.Account, repos []config.Repo) int {
+	var dirs []string
+	seen := map[string]bool{}
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, account := range accounts {
+		checkouts, err := accountCheckouts(cfg, account)
+		if err != nil {
+			output.Infof(quiet, "Skipping checkouts of %s: %v", account.Username, err)
+			continue
+		}
+		for _, dir := range checkouts {
+			add(dir)
+		}
+	}
+	for _, repo := range repos {
+		add(repo.Dir)
+	}
+
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+
+	deleted := 0
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		ignored := git.IgnoredFiles(dir)
+		if removeDryRun {
+			work, err := git.UnpushedWork(dir)
+			switch {
+			case err != nil:
+				yellow.Printf("  kept %s: %v\n", dir, err)
+			case work != "" && !removeForce:
+				yellow.Printf("  kept %s: %s (use --force to delete it anyway)\n", dir, work)
+			default:
+				fmt.Printf("  would delete %s%s\n", dir, discarded(work))
+				warnIgnored(ignored)
+				deleted++
+			}
+			continue
+		}
+
+		work, removed, err := git.RemoveCheckout(dir, removeForce)
+		switch {
+		case err != nil:
+			yellow.Printf("  kept %s: %v\n", dir, err)
+		case !removed:
+			yellow.Printf("  kept %s: %s (use --force to delete it anyway)\n", dir, work)
+		default:
+			green.Printf("  deleted %s%s\n", dir, discarded(work))
+			warnIgnored(ignored)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// warnIgnored warns about the ignored files, such as a local .env, that go
+// with a deleted checkout.
+func warnIgnored(files []string) {
+	if len(files) == 0 {
+		return
+	}
+	names := files
+	if len(names) > 3 {
+		names = append(names[:3:3], fmt.Sprintf("and %d more", len(files)-3))
+	}
+	color.New(color.FgYellow).Printf("    with %d ignored files: %s\n", len(files), strings.Join(names, ", "))
+}
+
+// discarded describes the unpushed work --force deletes with a checkout.
+func discarded(work string) string {
+	if work == "" {
+		return ""
+	}
+	return " (discarding " + work + ")"
+}
+
 func runConfigPrune(cmd *cobra.Command, args []string) error {
-	path := config.DefaultPath()
-	cfg, err := config.Load(path)
+	path := configPath()
+	cfg, err := loadConfigFile()
 	if err != nil {
 		return fmt.Errorf("no config found at %s — run 'gitall config init' to create one", path)
 	}
@@ -141,6 +513,104 @@ func runConfigPrune(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	path := configPath()
+	source := path
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		source = config.LegacyPath(path)
+		if _, err := os.Stat(source); os.IsNotExist(err) {
+			return fmt.Errorf("no config found at %s — run 'gitall config init' to create one", path)
+		}
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	migration, err := config.Migrate(data)
+	if err != nil {
+		return err
+	}
+	if !migration.Changed() {
+		fmt.Printf("Config at %s is already at version %d.\n", path, config.CurrentVersion)
+		return nil
+	}
+	if _, err := config.Parse(migration.Data, filepath.Dir(path)); err != nil {
+		return fmt.Errorf("migrated config is not valid: %w", err)
+	}
+
+	bold := color.New(color.Bold)
+	bold.Printf("Migrating %s to version %d\n", source, config.CurrentVersion)
+	for _, step := range migration.Steps {
+		fmt.Printf("  - %s\n", step)
+	}
+	fmt.Println()
+	printDiff(config.Diff(string(data), string(migration.Data)))
+
+	if migrateDryRun {
+		fmt.Printf("\nDry run — %s not written.\n", path)
+		return nil
+	}
+
+	backup, err := config.BackupFile(source)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, migration.Data, 0o644); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	if source != path {
+		if err := os.Remove(source); err != nil {
+			return fmt.Errorf("removing %s: %w", source, err)
+		}
+	}
+
+	fmt.Printf("\nBacked up %s to %s\n", source, backup)
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+// printDiff prints changed lines with three lines of context, marking
+// skipped stretches of unchanged lines with "...".
+func printDiff(lines []config.DiffLine) {
+	const context = 3
+	red := color.New(color.FgRed)
+	green := color.New(color.FgGreen)
+
+	near := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.Op == ' ' {
+			continue
+		}
+		for j := max(0, i-context); j <= min(len(lines)-1, i+context); j++ {
+			near[j] = true
+		}
+	}
+
+	skipped := false
+	for i, line := range lines {
+		if !near[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			fmt.Println("  ...")
+			skipped = false
+		}
+		switch line.Op {
+		case '-':
+			red.Printf("- %s\n", line.Text)
+		case '+':
+			green.Printf("+ %s\n", line.Text)
+		default:
+			fmt.Printf("  %s\n", line.Text)
+		}
+	}
+	if skipped {
+		fmt.Println("  ...")
+	}
+}
+
 func runConfigDiscover(cmd *cobra.Command, args []string) error {
 	discoveredPaths, err := git.DiscoverReposRecursive(discoverDir)
 	if err != nil {
@@ -152,38 +622,41 @@ func runConfigDiscover(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	path := configPath()
+	cfg, err := loadConfigFile()
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	providers := remoteProviders(cfg)
+
 	var repos []config.Repo
 
 	for _, repoPath := range discoveredPaths {
-		owner := git.RemoteOwner(repoPath)
-		if owner == "" {
-			continue
+		if repo, ok := checkoutRepo(providers, repoPath); ok {
+			repos = append(repos, repo)
 		}
-
-		protocol := git.RemoteProtocol(repoPath)
-		name := git.RepoNameFromPath(repoPath)
-		lowerOwner := strings.ToLower(owner)
-
-		repos = append(repos, config.Repo{
-			Name:     name,
-			Owner:    lowerOwner,
-			Dir:      repoPath,
-			Protocol: protocol,
-		})
 	}
 
 	if len(repos) == 0 {
-		fmt.Println("No repos with GitHub remotes found.")
+		fmt.Println("No repos with recognised remotes found.")
 		return nil
 	}
 
 	bold := color.New(color.Bold)
 	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
 
 	bold.Printf("Discovered %d repo(s):\n\n", len(repos))
 	for _, repo := range repos {
 		green.Printf("  %s", repo.Name)
-		fmt.Printf("  %s/%s  (%s)\n", repo.Owner, repo.Name, repo.Protocol)
+		if repo.Host != "" {
+			fmt.Printf("  %s/%s/%s  (%s)\n", repo.Host, repo.Owner, repo.Name, repo.Protocol)
+		} else {
+			fmt.Printf("  %s/%s  (%s)\n", repo.Owner, repo.Name, repo.Protocol)
+		}
+		if expected, ok := layoutPath(cfg, repo); ok && !strings.EqualFold(expected, repo.Dir) {
+			yellow.Printf("    outside layout, expected %s\n", expected)
+		}
 	}
 
 	if discoverDryRun {
@@ -191,12 +664,6 @@ func runConfigDiscover(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	path := config.DefaultPath()
-	cfg, err := config.Load(path)
-	if err != nil {
-		cfg = &config.Config{}
-	}
-
 	addedRepos := 0
 	for _, repo := range repos {
 		if err := cfg.AddRepo(repo); err == nil {
@@ -216,3 +683,53 @@ func runConfigDiscover(cmd *cobra.Command, args []string) error {
 	fmt.Printf("\nAdded %d repo(s) to %s\n", addedRepos, path)
 	return nil
 }
+
+// checkoutRepo builds a config entry for a checkout from its origin remote.
+// Remotes on a host no provider claims are recorded as GitHub repos, as they
+// were before providers existed.
+func checkoutRepo(providers []provider.Provider, repoPath string) (config.Repo, bool) {
+	remoteURL := git.RemoteURL(repoPath)
+	providerName := ""
+	p, remote, ok := recogniseRemote(providers, remoteURL)
+	if ok {
+		providerName = repoProvider(p.Name())
+	} else if remote, ok = git.ParseRemoteURL(remoteURL); !ok {
+		return config.Repo{}, false
+	}
+
+	return config.Repo{
+		Name:     git.RepoNameFromPath(repoPath),
+		Owner:    strings.ToLower(remote.Owner),
+		Provider: providerName,
+		Host:     repoHost(remote.Host),
+		Dir:      repoPath,
+		Protocol: git.RemoteProtocol(repoPath),
+	}, true
+}
+
+// layoutPath returns where the configured layout would put a repo. It
+// reports false when the layout needs values a local checkout cannot supply
+// (topics, or the dir of an account that is not configured) and for
+// manifest accounts, whose manifest sets the paths.
+func layoutPath(cfg *config.Config, repo config.Repo) (string, bool) {
+	if cfg.Layout.Uses("topic") {
+		return "", false
+	}
+	if account := cfg.FindAccount(repo.Owner); account != nil && account.Manifest != "" {
+		return "", false
+	}
+
+	vars := layout.Vars{Owner: repo.Owner, Name: repo.Name, Host: repo.Host}
+	if vars.Host == "" {
+		vars.Host = "github.com"
+	}
+	if account := cfg.FindAccount(repo.Owner); account != nil {
+		vars.Dir = account.Dir
+	}
+
+	expected, err := cfg.Layout.Path(vars)
+	if err != nil {
+		return "", false
+	}
+	return expected, true
+}
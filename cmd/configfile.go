@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/boycook/gitall/internal/config"
+)
+
+// configOverride returns the config file named with --config or
+// GITALL_CONFIG, or "" to use the layered files.
+func configOverride() string {
+	if configFile != "" {
+		return configFile
+	}
+	return os.Getenv("GITALL_CONFIG")
+}
+
+// userSource returns the user file, or the override that replaces it.
+func userSource() config.Source {
+	if override := configOverride(); override != "" {
+		return config.Source{Scope: config.ScopeFile, Path: override}
+	}
+	return config.Source{Scope: config.ScopeUser, Path: config.DefaultPath()}
+}
+
+// activeProfile returns the profile chosen with --profile, GITALL_PROFILE or
+// the user file's profile: key, or "" for none.
+func activeProfile() string {
+	if profileName != "" {
+		return profileName
+	}
+	if env := os.Getenv("GITALL_PROFILE"); env != "" {
+		return env
+	}
+	return config.DefaultProfileName(userSource().Path)
+}
+
+// configSource returns the file that commands which change the config write
+// to: the active profile, or else the user file or override. A profile that
+// does not exist yet gets a new file under ~/.gitall/profiles.
+func configSource() config.Source {
+	user := userSource()
+	name := activeProfile()
+	if name == "" {
+		return user
+	}
+	if source, err := config.FindProfile(user, name); err == nil {
+		return source
+	}
+	return config.Source{Scope: config.ScopeProfile, Path: config.ProfilePath(name)}
+}
+
+func configPath() string {
+	return configSource().Path
+}
+
+// configSources lists the config files to merge, lowest precedence first.
+// The active profile takes the place of the user file.
+func configSources() ([]config.Source, error) {
+	user := userSource()
+	if name := activeProfile(); name != "" {
+		source, err := config.FindProfile(user, name)
+		if err != nil {
+			return nil, err
+		}
+		user = source
+	}
+	if configOverride() != "" {
+		return []config.Source{user}, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = "."
+	}
+	return config.Sources(wd, user), nil
+}
+
+// loadConfig loads the layered config that commands act on.
+func loadConfig() (*config.Config, error) {
+	sources, err := configSources()
+	if err != nil {
+		return nil, err
+	}
+	return config.LoadSources(sources)
+}
+
+// loadConfigFile loads only the file that configPath names, within the
+// active profile, for commands that change it.
+func loadConfigFile() (*config.Config, error) {
+	return config.LoadSources([]config.Source{configSource()})
+}
@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/doctor"
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/github"
+	"github.com/boycook/gitall/internal/provider"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment and config for problems",
+	Long: `Check everything a gitall run depends on: the git version, the SSH
+agent and a key for every host cloned over ssh, API access and token scopes
+for every active account, the config file and its directories, whether each
+configured repo's origin matches its owner, name and protocol, duplicate or
+nested directories, and index.lock files left behind by crashed git
+processes. Each problem comes with a hint on how to fix it.
+
+Exits non-zero when any check fails.`,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	results := []doctor.Result{doctor.CheckGit()}
+
+	cfg, configResults := doctorConfig()
+	results = append(results, configResults...)
+	if cfg != nil {
+		results = append(results, doctorSSH(cfg)...)
+		results = append(results, doctorAPIs(cfg)...)
+		results = append(results, doctorDirs(cfg)...)
+		results = append(results, doctorCheckouts(cfg)...)
+	}
+
+	failed := doctor.Count(results, doctor.Fail)
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]any{
+			"checks": results,
+			"summary": map[string]int{
+				"pass": doctor.Count(results, doctor.Pass),
+				"warn": doctor.Count(results, doctor.Warn),
+				"fail": failed,
+			},
+		})
+	} else {
+		printDoctorResults(results)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// doctorConfig loads the config files, reporting missing, outdated or
+// invalid ones. It returns a nil config when there is nothing to check
+// further.
+func doctorConfig() (*config.Config, []doctor.Result) {
+	sources, err := configSources()
+	if err != nil {
+		return nil, []doctor.Result{{
+			Check:   "config",
+			Subject: "profile " + activeProfile(),
+			Status:  doctor.Fail,
+			Message: err.Error(),
+			Hint:    "create it with 'gitall --profile <name> config init', or switch with 'gitall profile use'",
+		}}
+	}
+
+	var warnings []doctor.Result
+	for _, source := range sources {
+		file := source.Path
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			file = config.LegacyPath(file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if migration, err := config.Migrate(data); err == nil && migration.Changed() {
+			message := fmt.Sprintf("written for config version %d, current is %d", migration.From, config.CurrentVersion)
+			if migration.From == config.CurrentVersion {
+				message = "has no version: field"
+			}
+			hint := "run 'gitall config migrate' to upgrade it"
+			if source.Path != configPath() {
+				hint = fmt.Sprintf("run 'gitall config migrate --config %s' to upgrade it", source.Path)
+			}
+			warnings = append(warnings, doctor.Result{
+				Check:   "config",
+				Subject: file,
+				Status:  doctor.Warn,
+				Message: message,
+				Hint:    hint,
+			})
+		}
+	}
+
+	cfg, err := config.LoadSources(sources)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, []doctor.Result{{
+			Check:   "config",
+			Subject: configPath(),
+			Status:  doctor.Fail,
+			Message: "no config file",
+			Hint:    "run 'gitall config init', or 'gitall config discover --dir <dir>' to build one from existing checkouts",
+		}}
+	}
+	if err != nil {
+		return nil, append(warnings, doctor.Result{
+			Check:   "config",
+			Subject: configPath(),
+			Status:  doctor.Fail,
+			Message: err.Error(),
+			Hint:    "fix the config file, or start again with 'gitall config init'",
+		})
+	}
+
+	var results []doctor.Result
+	for _, source := range cfg.Sources() {
+		accounts, repos := 0, 0
+		for _, account := range cfg.Accounts {
+			if account.Origin() == source.Path {
+				accounts++
+			}
+		}
+		for _, repo := range cfg.Repos {
+			if repo.Origin() == source.Path {
+				repos++
+			}
+		}
+		results = append(results, doctor.Result{
+			Check:   "config",
+			Subject: source.Path,
+			Status:  doctor.Pass,
+			Message: fmt.Sprintf("%s file, %d account(s), %d repo(s)", source.Scope, accounts, repos),
+		})
+	}
+	return cfg, append(results, warnings...)
+}
+
+// doctorSSH checks the SSH agent and tries a login to every host that an
+// active account or repo clones from over ssh.
+func doctorSSH(cfg *config.Config) []doctor.Result {
+	hosts := map[string]bool{}
+	for _, account := range cfg.ActiveAccounts() {
+		if account.Protocol != "ssh" {
+			continue
+		}
+		if p, err := newProvider(account); err == nil && p.Host() != "" {
+			hosts[sshHost(p.Host())] = true
+		}
+	}
+	for _, repo := range cfg.Repos {
+		if repo.Protocol != "ssh" || !cfg.IsOwnerActive(repo.Owner) {
+			continue
+		}
+		host := repo.Host
+		if host == "" {
+			host = "github.com"
+		}
+		hosts[sshHost(host)] = true
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	sorted := make([]string, 0, len(hosts))
+	for host := range hosts {
+		sorted = append(sorted, host)
+	}
+	sort.Strings(sorted)
+
+	results := []doctor.Result{doctor.CheckSSHAgent()}
+	for _, host := range sorted {
+		results = append(results, doctor.CheckSSHHost(host))
+	}
+	return results
+}
+
+// sshHost drops the port of a web host, since git's ssh port is a
+// different one.
+func sshHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return hostname
+	}
+	return host
+}
+
+func doctorAPIs(cfg *config.Config) []doctor.Result {
+	var results []doctor.Result
+	for _, account := range cfg.ActiveAccounts() {
+		if account.IsGitHub() {
+			results = append(results, doctorGitHub(account))
+		} else {
+			results = append(results, doctorProvider(account))
+		}
+	}
+	return results
+}
+
+// doctorGitHub checks that the API answers and, with a token, who it
+// belongs to and whether a classic token has the scopes listing needs.
+func doctorGitHub(account config.Account) doctor.Result {
+	result := doctor.Result{Check: "api", Subject: account.Username}
+	apiURL := github.NormalizeAPIURL(account.APIURL)
+	client := newGitHubClient(account.APIURL, account.Token)
+	token := resolveToken(account.Token, apiURL)
+
+	if !token.IsSet() {
+		if _, err := client.RateLimit(); err != nil {
+			return apiFailure(result, err)
+		}
+		result.Status = doctor.Warn
+		result.Message = "reachable, but no token — private repos are not listed and the rate limit is low"
+		result.Hint = "set GITHUB_TOKEN or the account's token; 'gitall auth status' shows where tokens are looked up"
+		return result
+	}
+
+	info, err := client.TokenInfo()
+	if err != nil {
+		return apiFailure(result, err)
+	}
+
+	result.Status = doctor.Pass
+	result.Message = fmt.Sprintf("authenticated as %s (token from %s)", info.Login, token.Source)
+	switch {
+	case info.Scopes == nil:
+		result.Message += "; fine-grained token, scopes not reported"
+	case !info.HasScope("repo"):
+		result.Status = doctor.Warn
+		result.Message += "; token lacks the repo scope"
+		result.Hint = "private repos are not listed — add the repo scope to the token"
+	case account.Mode == "team" && !info.HasScope("read:org"):
+		result.Status = doctor.Warn
+		result.Message += "; token lacks the read:org scope"
+		result.Hint = "team listing needs read:org — add it to the token"
+	}
+	return result
+}
+
+// doctorProvider lists an account's repos on another forge, which checks
+// reachability, the token and the listing mode at once.
+func doctorProvider(account config.Account) doctor.Result {
+	result := doctor.Result{Check: "api", Subject: account.Username}
+	p, err := newProvider(account)
+	if err == nil {
+		var repos []provider.Repo
+		if repos, err = p.ListRepos(provider.Filter{}); err == nil {
+			result.Status = doctor.Pass
+			result.Message = fmt.Sprintf("%s lists %d repo(s)", p.Name(), len(repos))
+			return result
+		}
+	}
+	return apiFailure(result, err)
+}
+
+// apiFailure fills in a failed API check. Errors without an API status are
+// network problems.
+func apiFailure(result doctor.Result, err error) doctor.Result {
+	result.Status = doctor.Fail
+	result.Message = err.Error()
+	result.Hint = errorHint(err)
+	if result.Hint == "" {
+		result.Hint = "check your network or proxy and the account's api_url"
+	}
+	return result
+}
+
+// doctorDirs checks the directories the config points at, reporting missing
+// repo dirs individually and the rest as one row.
+func doctorDirs(cfg *config.Config) []doctor.Result {
+	var results []doctor.Result
+	for _, account := range cfg.ActiveAccounts() {
+		results = append(results, doctor.CheckDir("dir", "account "+account.Username, account.Dir, "run 'gitall clone' to create it"))
+	}
+	if cfg.Layout.Root != "" {
+		results = append(results, doctor.CheckDir("dir", "layout root", cfg.Layout.Root, "create it, or run 'gitall clone'"))
+	}
+	if cfg.Backup.Dir != "" {
+		results = append(results, doctor.CheckDir("dir", "backup", cfg.Backup.Dir, "run 'gitall backup' to create it"))
+	}
+
+	var repoResults []doctor.Result
+	for _, repo := range cfg.Repos {
+		if cfg.IsOwnerActive(repo.Owner) {
+			repoResults = append(repoResults, doctor.CheckDir("dir", "repo "+repo.Owner+"/"+repo.Name, repo.Dir, "run 'gitall clone', or 'gitall config prune' to drop it"))
+		}
+	}
+	results = append(results, collapsePasses(repoResults, "dir", "repo dir(s) exist")...)
+
+	var dirs []doctor.Dir
+	for _, account := range cfg.Accounts {
+		dirs = append(dirs, doctor.Dir{Label: "account " + account.Username, Path: account.Dir, Account: true})
+	}
+	for _, repo := range cfg.Repos {
+		dirs = append(dirs, doctor.Dir{Label: "repo " + repo.Owner + "/" + repo.Name, Path: repo.Dir})
+	}
+	overlaps := doctor.CheckOverlaps(dirs)
+	if len(overlaps) == 0 {
+		overlaps = []doctor.Result{{Check: "dirs", Status: doctor.Pass, Message: "no duplicate or nested dirs"}}
+	}
+	return append(results, overlaps...)
+}
+
+// doctorCheckouts compares each configured repo's origin with its entry and
+// looks for stale index.lock files in every checkout.
+func doctorCheckouts(cfg *config.Config) []doctor.Result {
+	providers := remoteProviders(cfg)
+
+	var origins []doctor.Result
+	var checkouts []string
+	seen := map[string]bool{}
+	for _, repo := range cfg.Repos {
+		if !cfg.IsOwnerActive(repo.Owner) || !isDirectory(repo.Dir) {
+			continue
+		}
+		remoteURL := git.RemoteURL(repo.Dir)
+		_, remote, ok := recogniseRemote(providers, remoteURL)
+		if !ok {
+			remote, ok = git.ParseRemoteURL(remoteURL)
+		}
+		origins = append(origins, doctor.CheckOrigin(repo, remote, ok, git.RemoteProtocol(repo.Dir)))
+
+		seen[repo.Dir] = true
+		checkouts = append(checkouts, repo.Dir)
+	}
+	for _, account := range cfg.ActiveAccounts() {
+		paths, err := accountCheckouts(cfg, account)
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			if !seen[path] {
+				seen[path] = true
+				checkouts = append(checkouts, path)
+			}
+		}
+	}
+
+	results := collapsePasses(origins, "origin", "origin(s) match the config")
+
+	now := time.Now()
+	locks := 0
+	for _, path := range checkouts {
+		if result, found := doctor.CheckIndexLock(path, now); found {
+			results = append(results, result)
+			locks++
+		}
+	}
+	if locks == 0 && len(checkouts) > 0 {
+		results = append(results, doctor.Result{
+			Check:   "lock",
+			Status:  doctor.Pass,
+			Message: fmt.Sprintf("no index.lock in %d checkout(s)", len(checkouts)),
+		})
+	}
+	return results
+}
+
+// collapsePasses keeps the warnings and failures of a per-repo check and
+// replaces its passes with a single row, so large configs stay readable.
+func collapsePasses(results []doctor.Result, check, message string) []doctor.Result {
+	var kept []doctor.Result
+	passed := 0
+	for _, result := range results {
+		if result.Status == doctor.Pass {
+			passed++
+		} else {
+			kept = append(kept, result)
+		}
+	}
+	if passed > 0 {
+		kept = append(kept, doctor.Result{
+			Check:   check,
+			Status:  doctor.Pass,
+			Message: fmt.Sprintf("%d %s", passed, message),
+		})
+	}
+	return kept
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func printDoctorResults(results []doctor.Result) {
+	statusColors := map[doctor.Status]*color.Color{
+		doctor.Pass: color.New(color.FgGreen),
+		doctor.Warn: color.New(color.FgYellow),
+		doctor.Fail: color.New(color.FgRed),
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tCHECK\tSUBJECT\tRESULT\tFIX")
+	fmt.Fprintln(w, "------\t-----\t-------\t------\t---")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", statusColors[r.Status].Sprint(r.Status), r.Check, r.Subject, r.Message, r.Hint)
+	}
+	w.Flush()
+
+	fmt.Println()
+	bold := color.New(color.Bold)
+	bold.Print("Doctor summary: ")
+	fmt.Printf("%s, %s, %s\n",
+		statusColors[doctor.Pass].Sprintf("%d passed", doctor.Count(results, doctor.Pass)),
+		statusColors[doctor.Warn].Sprintf("%d warnings", doctor.Count(results, doctor.Warn)),
+		statusColors[doctor.Fail].Sprintf("%d failed", doctor.Count(results, doctor.Fail)))
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/boycook/gitall/internal/github"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func reportError(err error) {
+	output.PrintError(output.ErrorReport{
+		Code:    github.ErrorCode(err),
+		Message: err.Error(),
+		Hint:    errorHint(err),
+	}, jsonOut)
+}
+
+// errorHint suggests how to fix an API failure. Each provider's error type
+// knows its own remedies, such as which variable holds the token.
+func errorHint(err error) string {
+	var hinted interface{ Hint() string }
+	if errors.As(err, &hinted) {
+		return hinted.Hint()
+	}
+	if errors.Is(err, provider.ErrNotCached) {
+		return "run once without --offline — only GitHub responses are cached for offline use"
+	}
+	return ""
+}
@@ -20,6 +20,7 @@ var (
 	fetchUser        string
 	fetchDir         string
 	fetchConcurrency int
+	fetchTags        tagFlags
 )
 
 func init() {
@@ -27,11 +28,16 @@ func init() {
 
 	fetchCmd.Flags().StringVar(&fetchUser, "user", "", "only fetch repos for this user's directory")
 	fetchCmd.Flags().StringVar(&fetchDir, "dir", "", "directory to scan (overrides config)")
+	fetchTags.register(fetchCmd)
 	fetchCmd.Flags().IntVarP(&fetchConcurrency, "concurrency", "j", 4, "number of concurrent fetches")
 }
 
 func runFetch(cmd *cobra.Command, args []string) error {
-	repoPaths, err := resolveRepoPaths(fetchUser, fetchDir)
+	sel, err := fetchTags.selector()
+	if err != nil {
+		return err
+	}
+	repoPaths, err := resolveRepoPaths(fetchUser, fetchDir, sel)
 	if err != nil {
 		return err
 	}
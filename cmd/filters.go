@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+// repoFilters holds the metadata filter flags shared by clone and sync.
+type repoFilters struct {
+	Topics      []string
+	Language    string
+	Visibility  string
+	PushedSince string
+	MaxSize     int
+}
+
+var validVisibilities = map[string]bool{"": true, "all": true, "public": true, "private": true, "internal": true}
+
+func (f *repoFilters) register(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&f.Topics, "topic", nil, "only repos tagged with this topic (repeatable, matches any)")
+	cmd.Flags().StringVar(&f.Language, "language", "", "only repos whose primary language is this")
+	cmd.Flags().StringVar(&f.Visibility, "visibility", "", "only repos with this visibility: all, public, private or internal")
+	cmd.Flags().StringVar(&f.PushedSince, "pushed-since", "", "only repos pushed since a date (2006-01-02) or period (30d, 12w, 6m, 1y)")
+	cmd.Flags().IntVar(&f.MaxSize, "max-size", 0, "skip repos larger than this many KB")
+}
+
+// apply copies the filters onto filter, validating them on the way.
+func (f *repoFilters) apply(filter *provider.Filter) error {
+	visibility := strings.ToLower(f.Visibility)
+	if !validVisibilities[visibility] {
+		return fmt.Errorf("invalid visibility %q (must be all, public, private or internal)", f.Visibility)
+	}
+	pushedAfter, err := provider.ParseSince(f.PushedSince, time.Now())
+	if err != nil {
+		return fmt.Errorf("--pushed-since: %w", err)
+	}
+	if f.MaxSize < 0 {
+		return fmt.Errorf("--max-size must not be negative")
+	}
+
+	filter.Topics = f.Topics
+	filter.Language = f.Language
+	filter.Visibility = visibility
+	filter.PushedAfter = pushedAfter
+	filter.MaxSize = f.MaxSize
+	return nil
+}
+
+// tagFlags holds the --tag and --select flags that pick configured accounts
+// and repos by their tags.
+type tagFlags struct {
+	Tags   []string
+	Select string
+}
+
+func (f *tagFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&f.Tags, "tag", nil, "only repos with this tag; prefix with ! to exclude (repeatable)")
+	cmd.Flags().StringVar(&f.Select, "select", "", "only repos matching a tag expression, e.g. backend,!legacy")
+}
+
+func (f *tagFlags) selector() (config.Selector, error) {
+	terms := f.Tags
+	if f.Select != "" {
+		terms = append(terms, f.Select)
+	}
+	return config.ParseSelector(terms...)
+}
@@ -21,6 +21,7 @@ var (
 	listUser        string
 	listDir         string
 	listConcurrency int
+	listTags        tagFlags
 )
 
 func init() {
@@ -28,11 +29,16 @@ func init() {
 
 	listCmd.Flags().StringVar(&listUser, "user", "", "only list repos for this user")
 	listCmd.Flags().StringVar(&listDir, "dir", "", "directory to scan (overrides config)")
+	listTags.register(listCmd)
 	listCmd.Flags().IntVarP(&listConcurrency, "concurrency", "j", 8, "number of concurrent checks")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	repoPaths, err := resolveRepoPaths(listUser, listDir)
+	sel, err := listTags.selector()
+	if err != nil {
+		return err
+	}
+	repoPaths, err := resolveRepoPaths(listUser, listDir, sel)
 	if err != nil {
 		return err
 	}
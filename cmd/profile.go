@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named profiles of accounts and repos",
+	Long: `Profiles are named workspaces, each with its own accounts, repos, tokens
+and settings, such as work and personal. A profile lives in the profiles:
+section of ~/.gitall/config.yaml or in its own file,
+~/.gitall/profiles/<name>.yaml, and takes the place of the user config
+while it is active.
+
+The profile comes from --profile, then GITALL_PROFILE, then the profile
+set with 'gitall profile use'. The default profile is the user config's
+own accounts and repos.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles and show the active one",
+	Args:  cobra.NoArgs,
+	RunE:  runProfileList,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the profile used when none is given",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileUse,
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a profile's accounts and repos (default: the active profile)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runProfileShow,
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileShowCmd)
+}
+
+type profileEntry struct {
+	Name   string        `json:"name"`
+	Source config.Source `json:"source"`
+	Active bool          `json:"active"`
+}
+
+// currentProfile returns the active profile, naming the default profile
+// when none is chosen.
+func currentProfile() string {
+	if name := activeProfile(); name != "" {
+		return name
+	}
+	return config.DefaultProfile
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	user := userSource()
+	profiles, err := config.ListProfiles(user)
+	if err != nil {
+		return err
+	}
+
+	active := currentProfile()
+	entries := []profileEntry{{
+		Name:   config.DefaultProfile,
+		Source: user,
+		Active: strings.EqualFold(active, config.DefaultProfile),
+	}}
+	for _, profile := range profiles {
+		entries = append(entries, profileEntry{
+			Name:   profile.Name,
+			Source: profile.Source,
+			Active: profile.Name == active,
+		})
+	}
+
+	if jsonOut {
+		data, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tPROFILE\tCONFIG")
+	for _, entry := range entries {
+		marker := ""
+		if entry.Active {
+			marker = color.GreenString("*")
+		}
+		where := entry.Source.Path
+		if entry.Source.Profile != "" {
+			where += " (profiles: section)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", marker, entry.Name, where)
+	}
+	w.Flush()
+	return nil
+}
+
+func runProfileUse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	user := userSource()
+	if _, err := config.FindProfile(user, name); err != nil {
+		return err
+	}
+	if err := config.SetDefaultProfile(user.Path, name); err != nil {
+		return err
+	}
+
+	if strings.EqualFold(name, config.DefaultProfile) {
+		fmt.Println("Switched to the default profile")
+	} else {
+		fmt.Printf("Switched to profile %s\n", name)
+	}
+	if env := os.Getenv("GITALL_PROFILE"); env != "" && env != name {
+		output.Infof(quiet, "GITALL_PROFILE=%s still takes precedence in this shell", env)
+	}
+	return nil
+}
+
+func runProfileShow(cmd *cobra.Command, args []string) error {
+	name := currentProfile()
+	if len(args) == 1 {
+		name = args[0]
+	}
+
+	source, err := config.FindProfile(userSource(), name)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadSources([]config.Source{source})
+	if err != nil {
+		return err
+	}
+
+	bold := color.New(color.Bold)
+	bold.Fprintf(os.Stdout, "Profile: %s\n", name)
+	bold.Fprintf(os.Stdout, "Config: %s (%s)\n\n", source.Path, describeScope(source))
+	printConfigEntries(cfg, config.Selector{}, false)
+	return nil
+}
@@ -1,8 +1,7 @@
 package cmd
 
 import (
-	"strings"
-
+	"github.com/boycook/gitall/internal/config"
 	"github.com/boycook/gitall/internal/git"
 	"github.com/boycook/gitall/internal/output"
 	"github.com/boycook/gitall/internal/runner"
@@ -22,6 +21,7 @@ var (
 	pullUser        string
 	pullDir         string
 	pullConcurrency int
+	pullTags        tagFlags
 	pullStash       bool
 	pullRebase      bool
 	pullOwnedOnly   bool
@@ -33,6 +33,7 @@ func init() {
 
 	pullCmd.Flags().StringVar(&pullUser, "user", "", "only pull repos for this user's directory")
 	pullCmd.Flags().StringVar(&pullDir, "dir", "", "directory to scan (overrides config)")
+	pullTags.register(pullCmd)
 	pullCmd.Flags().IntVarP(&pullConcurrency, "concurrency", "j", 4, "number of concurrent pulls")
 	pullCmd.Flags().BoolVar(&pullStash, "stash", false, "auto-stash dirty repos before pulling")
 	pullCmd.Flags().BoolVar(&pullRebase, "rebase", false, "use git pull --rebase")
@@ -46,7 +47,11 @@ func runPull(cmd *cobra.Command, args []string) error {
 		Rebase: pullRebase,
 	}
 
-	repoPaths, err := resolveRepoPaths(pullUser, pullDir)
+	sel, err := pullTags.selector()
+	if err != nil {
+		return err
+	}
+	repoPaths, err := resolveRepoPaths(pullUser, pullDir, sel)
 	if err != nil {
 		return err
 	}
@@ -84,7 +89,7 @@ func filterOwnedRepos(repos []string) []string {
 	var filtered []string
 	for _, repoPath := range repos {
 		repoOwner := git.RemoteOwner(repoPath)
-		if strings.EqualFold(repoOwner, owner) {
+		if config.OwnedBy(repoOwner, owner) {
 			filtered = append(filtered, repoPath)
 		} else {
 			output.Infof(quiet, "Skipping %s (owned by %s)", git.RepoNameFromPath(repoPath), repoOwner)
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/github"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/boycook/gitall/internal/reconcile"
+	"github.com/spf13/cobra"
+)
+
+var relocateCmd = &cobra.Command{
+	Use:   "relocate",
+	Short: "Detect repos renamed or transferred upstream and fix local checkouts",
+	Long: `Look up every local repo on GitHub by its stable repo ID (or by its
+origin URL, which GitHub redirects after a rename) and report repos that
+were renamed or moved to another owner.
+
+By default nothing is changed. Use --apply to update the origin URL (or
+that of the first remote, for checkouts cloned with --origin) and the
+config entry's name and owner, and --rename-dirs to also rename the
+checkout directory to match the new repo name.`,
+	RunE: runRelocate,
+}
+
+var (
+	relocateUser        string
+	relocateDir         string
+	relocateConcurrency int
+	relocateApply       bool
+	relocateRenameDirs  bool
+)
+
+func init() {
+	rootCmd.AddCommand(relocateCmd)
+
+	relocateCmd.Flags().StringVar(&relocateUser, "user", "", "only check repos for this user")
+	relocateCmd.Flags().StringVar(&relocateDir, "dir", "", "directory to scan (overrides config)")
+	relocateCmd.Flags().IntVarP(&relocateConcurrency, "concurrency", "j", 4, "number of concurrent lookups")
+	relocateCmd.Flags().BoolVar(&relocateApply, "apply", false, "update remotes and config for moved repos")
+	relocateCmd.Flags().BoolVar(&relocateRenameDirs, "rename-dirs", false, "also rename checkout directories (requires --apply)")
+}
+
+type relocateCheck struct {
+	Path   string
+	Entry  *config.Repo
+	Remote github.Repo
+	Host   string
+	Move   reconcile.Move
+	Moved  bool
+	Result git.RepoResult
+}
+
+func runRelocate(cmd *cobra.Command, args []string) error {
+	if relocateRenameDirs && !relocateApply {
+		return fmt.Errorf("--rename-dirs requires --apply")
+	}
+
+	repoPaths, err := resolveRepoPaths(relocateUser, relocateDir, config.Selector{})
+	if err != nil {
+		return err
+	}
+
+	path := configPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		cfg = &config.Config{}
+	}
+
+	output.Infof(quiet, "Checking %d repos for renames and transfers...", len(repoPaths))
+	checks := detectMovesConcurrently(cfg, repoPaths, relocateConcurrency)
+
+	results := make([]git.RepoResult, len(checks))
+	configChanged := false
+	for i := range checks {
+		check := &checks[i]
+		if check.Moved && relocateApply {
+			configChanged = applyMove(check) || configChanged
+		}
+		results[i] = check.Result
+		if check.Moved {
+			output.Progress(i+1, len(checks), check.Result, quiet)
+		}
+	}
+
+	if configChanged {
+		if err := config.Save(cfg, path); err != nil {
+			return err
+		}
+	}
+
+	output.PrintSummary(results, "Relocate", jsonOut)
+	return nil
+}
+
+func detectMovesConcurrently(cfg *config.Config, repos []string, concurrency int) []relocateCheck {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	checks := make([]relocateCheck, len(repos))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(idx int, repoPath string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			checks[idx] = detectMove(cfg, repoPath)
+		}(i, repo)
+	}
+
+	wg.Wait()
+	return checks
+}
+
+func detectMove(cfg *config.Config, repoPath string) relocateCheck {
+	check := relocateCheck{
+		Path:  repoPath,
+		Entry: cfg.FindRepoByDir(repoPath),
+		Result: git.RepoResult{
+			Name: git.RepoNameFromPath(repoPath),
+			Path: repoPath,
+		},
+	}
+
+	remote, ok := git.ParseRemoteURL(git.RemoteURL(repoPath))
+	if !ok {
+		check.Result.Status = git.Skipped
+		check.Result.Message = "no GitHub remote"
+		return check
+	}
+	owner, name := remote.Owner, remote.Name
+	if (check.Entry != nil && !isGitHub(check.Entry.Provider)) || !gitHubHost(cfg, remote.Host) {
+		check.Result.Status = git.Skipped
+		check.Result.Message = "relocate supports GitHub remotes only"
+		return check
+	}
+
+	client := clientForRemote(cfg, remote)
+	check.Host = client.Host()
+	var current *github.Repo
+	var err error
+	if check.Entry != nil && check.Entry.ID != 0 {
+		current, err = client.GetRepoByID(check.Entry.ID)
+	} else {
+		current, err = client.GetRepo(owner, name)
+	}
+	if err != nil {
+		check.Result.Status = git.Failed
+		check.Result.Message = err.Error()
+		return check
+	}
+
+	check.Remote = *current
+	check.Move, check.Moved = reconcile.DetectMove(repoPath, owner, name, *current)
+	if !check.Moved {
+		check.Result.Status = git.UpToDate
+		check.Result.Message = "not moved"
+		return check
+	}
+
+	check.Result.Status = git.Skipped
+	check.Result.Message = fmt.Sprintf("%s %s (use --apply to update)", describeMove(check.Move), check.Move)
+	return check
+}
+
+// gitHubHost reports whether a remote host belongs to GitHub rather than to
+// another provider, either a configured account or a public service.
+func gitHubHost(cfg *config.Config, host string) bool {
+	for _, p := range remoteProviders(cfg) {
+		if !isGitHub(p.Name()) && strings.EqualFold(p.Host(), host) {
+			return false
+		}
+	}
+	return true
+}
+
+// clientForRemote picks the API client for a checkout's remote: the account
+// for its owner on the same host, else any account on that host, else an
+// anonymous client for the host.
+func clientForRemote(cfg *config.Config, remote git.Remote) *github.Client {
+	if account := cfg.FindAccount(remote.Owner); account != nil && isGitHub(account.Provider) && github.WebHost(account.APIURL) == remote.Host {
+		return newGitHubClient(account.APIURL, account.Token)
+	}
+	for _, account := range cfg.Accounts {
+		if isGitHub(account.Provider) && github.WebHost(account.APIURL) == remote.Host {
+			return newGitHubClient(account.APIURL, account.Token)
+		}
+	}
+	return newGitHubClient(apiURLForHost(remote.Host), "")
+}
+
+func describeMove(move reconcile.Move) string {
+	switch {
+	case move.Renamed() && move.Transferred():
+		return "renamed and transferred"
+	case move.Transferred():
+		return "transferred"
+	default:
+		return "renamed"
+	}
+}
+
+// applyMove points origin at the repo's new location and updates its config
+// entry. It reports whether the config was modified.
+func applyMove(check *relocateCheck) bool {
+	move := check.Move
+	protocol := git.RemoteProtocol(check.Path)
+	newURL := github.CloneURLForHost(check.Remote, protocol, move.NewOwner, check.Host)
+
+	if err := git.SetRemoteURL(check.Path, newURL); err != nil {
+		check.Result.Status = git.Failed
+		check.Result.Message = fmt.Sprintf("updating origin: %v", err)
+		return false
+	}
+
+	changes := fmt.Sprintf("%s %s — origin updated", describeMove(move), move)
+	newPath := check.Path
+	if relocateRenameDirs && move.Renamed() {
+		renamed, err := reconcile.RenameDir(check.Path, move.NewName)
+		if err != nil {
+			check.Result.Status = git.Failed
+			check.Result.Message = fmt.Sprintf("%s, directory not renamed: %v", changes, err)
+			return updateMovedEntry(check.Entry, move, check.Path)
+		}
+		newPath = renamed
+		changes += ", directory renamed"
+	}
+
+	check.Result.Name = git.RepoNameFromPath(newPath)
+	check.Result.Path = newPath
+	check.Result.Status = git.Success
+	check.Result.Message = changes
+	if check.Entry != nil {
+		check.Result.Message += ", config updated"
+	}
+	return updateMovedEntry(check.Entry, move, newPath)
+}
+
+func updateMovedEntry(entry *config.Repo, move reconcile.Move, newPath string) bool {
+	if entry == nil {
+		return false
+	}
+	entry.ID = move.ID
+	entry.Name = move.NewName
+	entry.Owner = move.NewOwner
+	entry.Dir = newPath
+	return true
+}
@@ -1,33 +1,93 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"path/filepath"
 	"strings"
 
 	"github.com/boycook/gitall/internal/config"
 	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/layout"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/boycook/gitall/internal/provider"
 )
 
-func resolveRepoPaths(user, dir string) ([]string, error) {
+// resolveRepoPaths finds the checkouts to work on: those under dir, or
+// else every checkout the config knows about. Only checkouts whose tags
+// match sel are returned.
+func resolveRepoPaths(user, dir string, sel config.Selector) ([]string, error) {
 	if dir != "" {
 		repos, err := git.DiscoverRepos(dir)
+		if err != nil || sel.IsEmpty() {
+			return repos, err
+		}
+		cfg, err := loadConfig()
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("--tag and --select need a config to read tags from")
+		}
+		var selected []string
+		for _, repoPath := range repos {
+			abs, err := filepath.Abs(repoPath)
+			if err != nil {
+				return nil, err
+			}
+			if sel.Matches(cfg.DirTags(abs)) {
+				selected = append(selected, repoPath)
+			}
 		}
-		return repos, nil
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no matching repos found")
+		}
+		return selected, nil
 	}
 
-	cfg, err := config.Load(config.DefaultPath())
-	if err != nil {
+	cfg, err := loadConfig()
+	if errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("no config found.\nRun 'gitall config init' to create one, or use --dir to specify a directory")
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	var paths []string
+	seen := map[string]bool{}
+	addPath := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, account := range cfg.Accounts {
+		if user != "" && !strings.EqualFold(account.Username, user) {
+			continue
+		}
+		if !account.IsActive() {
+			output.Infof(quiet, "Skipping inactive account %s", account.Username)
+			continue
+		}
+		repos, err := accountCheckouts(cfg, account)
+		if err != nil {
+			output.Infof(quiet, "Skipping %s: %v", account.Username, err)
+			continue
+		}
+		for _, repoPath := range repos {
+			if sel.Matches(cfg.CheckoutTags(account, repoPath)) {
+				addPath(repoPath)
+			}
+		}
+	}
+
 	for _, repo := range cfg.Repos {
 		if user != "" && !strings.EqualFold(repo.Owner, user) {
 			continue
 		}
-		paths = append(paths, repo.Dir)
+		if !cfg.IsOwnerActive(repo.Owner) || !sel.Matches(cfg.RepoTags(repo)) {
+			continue
+		}
+		addPath(repo.Dir)
 	}
 
 	if len(paths) == 0 {
@@ -36,3 +96,29 @@ func resolveRepoPaths(user, dir string) ([]string, error) {
 
 	return paths, nil
 }
+
+// accountCheckouts finds an account's checkouts under the base dir of the
+// configured layout, searching recursively when the layout nests repos
+// below it (for example by topic).
+func accountCheckouts(cfg *config.Config, account config.Account) ([]string, error) {
+	p, err := newProvider(account)
+	if err != nil {
+		return nil, err
+	}
+	// Repos from a manifest pick their own paths below the account dir.
+	if p.Host() == "" {
+		return git.DiscoverReposRecursive(account.Dir)
+	}
+	base, nested, err := cfg.Layout.Base(layout.Vars{
+		Dir:   account.Dir,
+		Host:  p.Host(),
+		Owner: account.Username,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if g, ok := p.(provider.GroupProvider); nested || (ok && g.NestedGroups()) {
+		return git.DiscoverReposRecursive(base)
+	}
+	return git.DiscoverRepos(base)
+}
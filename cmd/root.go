@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -13,6 +13,13 @@ var (
 	verbose bool
 	quiet   bool
 	jsonOut bool
+	refresh bool
+	offline bool
+	retries int
+	maxWait time.Duration
+
+	configFile  string
+	profileName string
 )
 
 var rootCmd = &cobra.Command{
@@ -21,11 +28,15 @@ var rootCmd = &cobra.Command{
 	Long: `GitAll is a CLI tool for batch-managing GitHub repositories.
 Clone, pull, fetch, and check status across multiple user or
 organisation accounts with a single command.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Flags parsed fine, so any later error is not a usage problem.
+		cmd.SilenceUsage = true
+	},
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		reportError(err)
 		os.Exit(1)
 	}
 }
@@ -35,7 +46,15 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "suppress non-essential output")
 	rootCmd.PersistentFlags().BoolVar(&jsonOut, "json", false, "output in JSON format")
 	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "bypass the GitHub API response cache")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "answer GitHub API requests from the cache only")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 3, "retries for rate-limited or failed GitHub API requests")
+	rootCmd.PersistentFlags().DurationVar(&maxWait, "max-wait", 2*time.Minute, "maximum total time to wait on GitHub API retries")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "use only this config file (default: layered system, user and project files)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "use this named profile's accounts and repos (also GITALL_PROFILE)")
 	rootCmd.MarkFlagsMutuallyExclusive("json", "quiet")
+	rootCmd.MarkFlagsMutuallyExclusive("refresh", "offline")
 
+	rootCmd.SilenceErrors = true
 	rootCmd.Version = version
 }
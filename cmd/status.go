@@ -22,6 +22,7 @@ var (
 	statusUser        string
 	statusDir         string
 	statusConcurrency int
+	statusTags        tagFlags
 	statusAll         bool
 	statusFetch       bool
 )
@@ -31,13 +32,18 @@ func init() {
 
 	statusCmd.Flags().StringVar(&statusUser, "user", "", "only check repos for this user's directory")
 	statusCmd.Flags().StringVar(&statusDir, "dir", "", "directory to scan (overrides config)")
+	statusTags.register(statusCmd)
 	statusCmd.Flags().IntVarP(&statusConcurrency, "concurrency", "j", 8, "number of concurrent status checks")
 	statusCmd.Flags().BoolVar(&statusAll, "all", false, "show all repos including clean ones")
 	statusCmd.Flags().BoolVar(&statusFetch, "fetch", false, "fetch remotes before checking status for accurate behind counts")
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	repoPaths, err := resolveRepoPaths(statusUser, statusDir)
+	sel, err := statusTags.selector()
+	if err != nil {
+		return err
+	}
+	repoPaths, err := resolveRepoPaths(statusUser, statusDir, sel)
 	if err != nil {
 		return err
 	}
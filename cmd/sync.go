@@ -0,0 +1,407 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/output"
+	"github.com/boycook/gitall/internal/provider"
+	"github.com/boycook/gitall/internal/reconcile"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Reconcile remote repo lists with local checkouts",
+	Long: `Compare each configured account's repos on GitHub with the checkouts on
+disk and in the config. New remote repos are cloned, checkouts whose upstream
+was deleted are reported, and repos archived upstream are flagged.
+
+What happens to archived and deleted repos is controlled by the sync policy
+in the config (or --archived / --deleted): keep leaves them alone, archive
+moves the checkout into the archive directory, and remove drops the entry
+from the config.
+
+Only checkouts whose remote is the account's, or whose config entry has a
+repo ID the listing lacks, count as deleted. The deleted policy is only
+applied when the account was listed with a token and not through a team,
+since private and out-of-team repos are missing from such listings.`,
+	RunE: runSync,
+}
+
+var (
+	syncUser        string
+	syncConcurrency int
+	syncDryRun      bool
+	syncNoClone     bool
+	syncNoForks     bool
+	syncFilter      string
+	syncArchived    string
+	syncDeleted     string
+	syncArchiveDir  string
+	syncFilters     repoFilters
+	syncSettings    cloneSettingFlags
+)
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().StringVar(&syncUser, "user", "", "only sync this configured account")
+	syncCmd.Flags().IntVarP(&syncConcurrency, "concurrency", "j", 4, "number of concurrent clones")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "show what would change without cloning or moving anything")
+	syncCmd.Flags().BoolVar(&syncNoClone, "no-clone", false, "report missing repos without cloning them")
+	syncCmd.Flags().BoolVar(&syncNoForks, "no-forks", false, "do not clone forked repos")
+	syncCmd.Flags().StringVar(&syncFilter, "filter", "", "only clone repos matching this glob pattern")
+	syncCmd.Flags().StringVar(&syncArchived, "archived", "", "policy for repos archived upstream: keep, archive or remove")
+	syncCmd.Flags().StringVar(&syncDeleted, "deleted", "", "policy for repos deleted upstream: keep, archive or remove")
+	syncCmd.Flags().StringVar(&syncArchiveDir, "archive-dir", "", "directory archived checkouts are moved to (default <account dir>/.archived)")
+	syncFilters.register(syncCmd)
+	syncSettings.register(syncCmd)
+}
+
+type syncPolicies struct {
+	Archived   reconcile.Policy
+	Deleted    reconcile.Policy
+	ArchiveDir string
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	path := configPath()
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("no config found at %s — run 'gitall config init' to create one", path)
+	}
+
+	policies, err := resolveSyncPolicies(cfg)
+	if err != nil {
+		return err
+	}
+
+	cloneFilter := provider.Filter{
+		NoForks:    syncNoForks,
+		NoArchived: true,
+		Pattern:    syncFilter,
+	}
+	if err := syncFilters.apply(&cloneFilter); err != nil {
+		return err
+	}
+	overrides, err := syncSettings.settings()
+	if err != nil {
+		return err
+	}
+
+	accounts, err := selectAccounts(cfg, syncUser)
+	if err != nil {
+		return err
+	}
+
+	var jobs []cloneJob
+	var results []git.RepoResult
+	configChanged := false
+
+	reportQuota(accounts)
+
+	for _, account := range accounts {
+		output.Infof(quiet, "Listing repos for %s...", account.Username)
+		p, remote, err := listAccountRepos(account)
+		if err != nil {
+			return fmt.Errorf("listing repos for %s: %w", account.Username, err)
+		}
+
+		archiveDir := policies.ArchiveDir
+		if archiveDir == "" {
+			archiveDir = filepath.Join(account.Dir, ".archived")
+		} else {
+			archiveDir = filepath.Join(archiveDir, account.Username)
+		}
+
+		plan := reconcile.Compare(remote, localCheckouts(cfg, account, p, archiveDir))
+		output.Infof(quiet, "%s: %d in sync, %d missing, %d archived upstream, %d deleted upstream",
+			account.Username, plan.InSync, len(plan.Missing), len(plan.Archived), len(plan.Deleted))
+		if len(plan.Unknown) > 0 {
+			output.Infof(quiet, "%s: leaving %d checkout(s) of other owners' repos alone", account.Username, len(plan.Unknown))
+		}
+
+		missing := provider.FilterRepos(plan.Missing, cloneFilter)
+		accountJobs, err := buildCloneJobs(syncCloneAccount(cfg, account), p, missing, overrides)
+		if err != nil {
+			return fmt.Errorf("%s: %w", account.Username, err)
+		}
+		jobs = append(jobs, accountJobs...)
+
+		deletedPolicy, deletedReason := policies.Deleted, "deleted upstream"
+		if complete, why := listingComplete(account, p); !complete {
+			if deletedPolicy != reconcile.PolicyKeep && len(plan.Deleted) > 0 {
+				output.Errorf("Not applying the %s policy to %s: %s", deletedPolicy, account.Username, why)
+			}
+			deletedPolicy, deletedReason = reconcile.PolicyKeep, "not listed upstream ("+why+")"
+		}
+
+		for _, stale := range plan.Archived {
+			result, changed := applySyncPolicy(cfg, stale, policies.Archived, archiveDir, "archived upstream")
+			results = append(results, result)
+			configChanged = configChanged || changed
+		}
+		for _, stale := range plan.Deleted {
+			result, changed := applySyncPolicy(cfg, stale, deletedPolicy, archiveDir, deletedReason)
+			results = append(results, result)
+			configChanged = configChanged || changed
+		}
+	}
+
+	jobs, collided := splitCollisions(jobs, cfg.Repos)
+	results = append(results, collided...)
+
+	if syncDryRun {
+		items := make([]string, 0, len(jobs)+len(results))
+		for _, job := range jobs {
+			items = append(items, fmt.Sprintf("clone %s/%s -> %s", job.Repo.Owner, job.Repo.Name, job.Repo.Dir))
+		}
+		for _, result := range results {
+			items = append(items, fmt.Sprintf("%s: %s", result.Name, result.Message))
+		}
+		output.PrintDryRun(items, "sync")
+		return nil
+	}
+
+	for i, result := range results {
+		output.Progress(i+1, len(results), result, quiet)
+	}
+
+	if syncNoClone {
+		for _, job := range jobs {
+			results = append(results, git.RepoResult{
+				Name:    job.Repo.Name,
+				Path:    job.Repo.Dir,
+				Status:  git.Skipped,
+				Message: "missing locally (not cloned)",
+			})
+		}
+	} else if len(jobs) > 0 {
+		output.Infof(quiet, "Cloning %d new repos...", len(jobs))
+		results = append(results, cloneRepos(jobs, syncConcurrency)...)
+	}
+
+	if configChanged {
+		if err := config.Save(cfg, path); err != nil {
+			return err
+		}
+	}
+
+	output.PrintSummary(results, "Sync", jsonOut)
+	return nil
+}
+
+func resolveSyncPolicies(cfg *config.Config) (syncPolicies, error) {
+	archivedSetting := cfg.Sync.Archived
+	if syncArchived != "" {
+		archivedSetting = syncArchived
+	}
+	deletedSetting := cfg.Sync.Deleted
+	if syncDeleted != "" {
+		deletedSetting = syncDeleted
+	}
+
+	archived, err := reconcile.ParsePolicy(archivedSetting)
+	if err != nil {
+		return syncPolicies{}, fmt.Errorf("archived: %w", err)
+	}
+	deleted, err := reconcile.ParsePolicy(deletedSetting)
+	if err != nil {
+		return syncPolicies{}, fmt.Errorf("deleted: %w", err)
+	}
+
+	archiveDir := cfg.Sync.ArchiveDir
+	if syncArchiveDir != "" {
+		archiveDir = syncArchiveDir
+	}
+
+	return syncPolicies{Archived: archived, Deleted: deleted, ArchiveDir: archiveDir}, nil
+}
+
+// selectAccounts returns the active accounts, limited to user when set.
+func selectAccounts(cfg *config.Config, user string) ([]config.Account, error) {
+	var accounts []config.Account
+	for _, account := range cfg.Accounts {
+		if user != "" && !strings.EqualFold(account.Username, user) {
+			continue
+		}
+		if !account.IsActive() {
+			output.Infof(quiet, "Skipping inactive account %s", account.Username)
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no matching active accounts found in config")
+	}
+	return accounts, nil
+}
+
+// listAccountRepos lists every repo of an account through its provider. The
+// provider is returned too, for building clone URLs.
+func listAccountRepos(account config.Account) (provider.Provider, []provider.Repo, error) {
+	p, err := newProvider(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	repos, err := p.ListRepos(provider.Filter{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, repos, nil
+}
+
+// reportQuota prints the remaining API quota once per API host and token
+// before a sync starts, so large syncs do not stall on the rate limit unseen.
+func reportQuota(accounts []config.Account) {
+	if quiet || offline {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, account := range accounts {
+		if !isGitHub(account.Provider) {
+			continue
+		}
+		key := account.APIURL + "\x00" + resolveToken(account.Token, account.APIURL).Value()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		limit, err := newGitHubClient(account.APIURL, account.Token).RateLimit()
+		if err != nil {
+			continue
+		}
+		output.Infof(quiet, "GitHub API quota for %s: %s", account.Username, limit)
+		if limit.Remaining == 0 {
+			output.Errorf("Rate limit exhausted — requests will wait until %s or fail", limit.Reset.Local().Format("15:04"))
+		}
+	}
+}
+
+func syncCloneAccount(cfg *config.Config, account config.Account) cloneAccount {
+	ca := fromConfigAccount(account)
+	ca.RepoClone = repoCloneSettings(cfg, account.Username)
+	ca.Layout = cfg.Layout
+	ca.Configured = true
+	return ca
+}
+
+// listingComplete reports whether an account's listing includes every repo
+// it owns, which treating unlisted checkouts as deleted relies on. Without a
+// token, forges leave out private repos, and a team listing leaves out the
+// rest of the org.
+func listingComplete(account config.Account, p provider.Provider) (bool, string) {
+	if account.Team != "" || strings.EqualFold(account.Mode, "team") {
+		return false, "a team listing leaves out the rest of the org"
+	}
+	if p.Name() != "manifest" && !accountToken(account, p).IsSet() {
+		return false, "listed without a token, so private repos are hidden"
+	}
+	return true, ""
+}
+
+// localCheckouts returns the checkouts belonging to an account: every repo
+// under the account's layout dir plus any repo entries owned by the account
+// elsewhere. Checkouts already moved into archiveDir are left out.
+func localCheckouts(cfg *config.Config, account config.Account, p provider.Provider, archiveDir string) []reconcile.Local {
+	var local []reconcile.Local
+	seen := map[string]bool{}
+
+	discovered, _ := accountCheckouts(cfg, account)
+	for _, repoPath := range discovered {
+		if isWithin(repoPath, archiveDir) {
+			continue
+		}
+		seen[repoPath] = true
+		entry := cfg.FindRepoByDir(repoPath)
+		checkout := reconcile.Local{Name: git.RepoNameFromPath(repoPath), Path: repoPath}
+		if entry != nil {
+			checkout.ID = entry.ID
+		}
+		checkout.Owned = ownsCheckout(account, p, repoPath, entry)
+		local = append(local, checkout)
+	}
+
+	for i, repo := range cfg.Repos {
+		if !config.OwnedBy(repo.Owner, account.Username) || seen[repo.Dir] {
+			continue
+		}
+		seen[repo.Dir] = true
+		local = append(local, reconcile.Local{
+			Name:  repo.Name,
+			Path:  repo.Dir,
+			ID:    repo.ID,
+			Owned: ownsCheckout(account, p, repo.Dir, &cfg.Repos[i]),
+		})
+	}
+
+	return local
+}
+
+// ownsCheckout reports whether a checkout's remote is on the account's host
+// and owner. A checkout that is not on disk goes by its config entry.
+func ownsCheckout(account config.Account, p provider.Provider, repoPath string, entry *config.Repo) bool {
+	if remoteURL := git.RemoteURL(repoPath); remoteURL != "" {
+		remote, ok := p.ParseRemote(remoteURL)
+		return ok && config.OwnedBy(remote.Owner, account.Username)
+	}
+	if entry == nil || !config.OwnedBy(entry.Owner, account.Username) {
+		return false
+	}
+	return p.Host() == "" || strings.EqualFold(repoHost(p.Host()), entry.Host)
+}
+
+// isWithin reports whether path is dir or below it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func applySyncPolicy(cfg *config.Config, stale reconcile.Stale, policy reconcile.Policy, archiveDir, reason string) (git.RepoResult, bool) {
+	result := git.RepoResult{
+		Name: stale.Local.Name,
+		Path: stale.Local.Path,
+	}
+
+	switch policy {
+	case reconcile.PolicyArchive:
+		if syncDryRun {
+			result.Message = fmt.Sprintf("%s — would move to %s", reason, archiveDir)
+			return result, false
+		}
+		target, err := reconcile.MoveToArchive(stale.Local.Path, archiveDir)
+		if err != nil {
+			result.Status = git.Failed
+			result.Message = fmt.Sprintf("%s — %v", reason, err)
+			return result, false
+		}
+		result.Status = git.Success
+		result.Message = fmt.Sprintf("%s — moved to %s", reason, target)
+		return result, cfg.RemoveRepoByDir(stale.Local.Path)
+
+	case reconcile.PolicyRemove:
+		if syncDryRun {
+			result.Message = fmt.Sprintf("%s — would remove from config", reason)
+			return result, false
+		}
+		if !cfg.RemoveRepoByDir(stale.Local.Path) {
+			result.Status = git.Skipped
+			result.Message = fmt.Sprintf("%s — not in config, left on disk", reason)
+			return result, false
+		}
+		result.Status = git.Success
+		result.Message = fmt.Sprintf("%s — removed from config", reason)
+		return result, true
+
+	default:
+		result.Status = git.Skipped
+		result.Message = reason
+		return result, false
+	}
+}
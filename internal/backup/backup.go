@@ -0,0 +1,137 @@
+// Package backup tracks when each mirrored repo was last backed up.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StateFile is the name of the state file kept in the backup dir.
+const StateFile = ".gitall-backup.json"
+
+type Entry struct {
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// State maps a backup key such as "github.com/owner/name" to its history.
+type State struct {
+	Repos map[string]*Entry `json:"repos"`
+}
+
+// LoadState reads the state file in dir. A missing file is an empty state.
+func LoadState(dir string) (*State, error) {
+	state := &State{Repos: map[string]*Entry{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading backup state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parsing backup state: %w", err)
+	}
+	if state.Repos == nil {
+		state.Repos = map[string]*Entry{}
+	}
+	return state, nil
+}
+
+func (s *State) Save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding backup state: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, StateFile), data, 0o644); err != nil {
+		return fmt.Errorf("writing backup state: %w", err)
+	}
+	return nil
+}
+
+// Record notes the outcome of one backup attempt. A failure keeps the
+// previous last-success time so staleness keeps growing.
+func (s *State) Record(key string, at time.Time, err error) {
+	entry, ok := s.Repos[key]
+	if !ok {
+		entry = &Entry{}
+		s.Repos[key] = entry
+	}
+
+	entry.LastAttempt = at
+	if err != nil {
+		entry.LastError = err.Error()
+		return
+	}
+	entry.LastSuccess = at
+	entry.LastError = ""
+}
+
+// Stale is a backup whose last success is older than the threshold.
+type Stale struct {
+	Key         string
+	LastSuccess time.Time // zero if it never succeeded
+	LastError   string
+}
+
+func (s Stale) Age(now time.Time) string {
+	if s.LastSuccess.IsZero() {
+		return "never"
+	}
+	return now.Sub(s.LastSuccess).Round(time.Minute).String()
+}
+
+// Stale lists backups that have not succeeded within maxAge, oldest first.
+func (s *State) Stale(maxAge time.Duration, now time.Time) []Stale {
+	var stale []Stale
+	for key, entry := range s.Repos {
+		if now.Sub(entry.LastSuccess) > maxAge {
+			stale = append(stale, Stale{Key: key, LastSuccess: entry.LastSuccess, LastError: entry.LastError})
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		if !stale[i].LastSuccess.Equal(stale[j].LastSuccess) {
+			return stale[i].LastSuccess.Before(stale[j].LastSuccess)
+		}
+		return stale[i].Key < stale[j].Key
+	})
+	return stale
+}
+
+// ParseMaxAge parses a Go duration such as 36h, or a number of days like 7d.
+func ParseMaxAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid max age %q (use e.g. 36h or 7d)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid max age %q (use e.g. 36h or 7d)", s)
+	}
+	return d, nil
+}
+
+// FormatMaxAge is the inverse of ParseMaxAge for display: whole days print
+// as 7d, anything else as a Go duration.
+func FormatMaxAge(d time.Duration) string {
+	day := 24 * time.Hour
+	if d > 0 && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestState_SaveAndLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	state, err := LoadState(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state.Record("github.com/me/repo", at, nil)
+	if err := state.Save(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadState(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry := loaded.Repos["github.com/me/repo"]
+	if entry == nil || !entry.LastSuccess.Equal(at) {
+		t.Errorf("expected last success %v, got %+v", at, entry)
+	}
+}
+
+func TestState_FailureKeepsLastSuccess(t *testing.T) {
+	state := &State{Repos: map[string]*Entry{}}
+	first := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	state.Record("repo", first, nil)
+	state.Record("repo", second, errors.New("network down"))
+
+	entry := state.Repos["repo"]
+	if !entry.LastSuccess.Equal(first) {
+		t.Errorf("expected last success %v, got %v", first, entry.LastSuccess)
+	}
+	if !entry.LastAttempt.Equal(second) || entry.LastError != "network down" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestState_Stale(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	state := &State{Repos: map[string]*Entry{
+		"fresh":  {LastSuccess: now.Add(-time.Hour)},
+		"old":    {LastSuccess: now.Add(-10 * 24 * time.Hour)},
+		"never":  {LastAttempt: now, LastError: "denied"},
+		"edging": {LastSuccess: now.Add(-7 * 24 * time.Hour)},
+	}}
+
+	stale := state.Stale(7*24*time.Hour, now)
+
+	if len(stale) != 2 || stale[0].Key != "never" || stale[1].Key != "old" {
+		t.Fatalf("expected [never old], got %+v", stale)
+	}
+	if stale[0].Age(now) != "never" {
+		t.Errorf("expected age never, got %q", stale[0].Age(now))
+	}
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"0d":  0,
+	}
+	for input, expected := range tests {
+		got, err := ParseMaxAge(input)
+		if err != nil || got != expected {
+			t.Errorf("ParseMaxAge(%q) = %v, %v; expected %v", input, got, err, expected)
+		}
+	}
+
+	for _, input := range []string{"week", "-1d", "7x"} {
+		if _, err := ParseMaxAge(input); err == nil {
+			t.Errorf("ParseMaxAge(%q): expected error", input)
+		}
+	}
+}
+
+func TestFormatMaxAge(t *testing.T) {
+	if got := FormatMaxAge(7 * 24 * time.Hour); got != "7d" {
+		t.Errorf("expected 7d, got %q", got)
+	}
+	if got := FormatMaxAge(36 * time.Hour); got != "36h0m0s" {
+		t.Errorf("expected 36h0m0s, got %q", got)
+	}
+}
@@ -0,0 +1,227 @@
+// Package bitbucket lists repos in a Bitbucket Cloud workspace through the
+// REST API 2.0.
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+const (
+	defaultAPIURL = "https://api.bitbucket.org/2.0"
+	defaultHost   = "bitbucket.org"
+)
+
+// Credentials authenticate API requests: an Atlassian username with an app
+// password, or an access token on its own.
+type Credentials struct {
+	Username    string
+	AppPassword string
+	Token       string
+}
+
+// ParseCredentials reads a configured token. "user:app-password" is an app
+// password; anything else is sent as a bearer access token.
+func ParseCredentials(token string) Credentials {
+	if user, password, ok := strings.Cut(token, ":"); ok {
+		return Credentials{Username: user, AppPassword: password}
+	}
+	return Credentials{Token: token}
+}
+
+type Client struct {
+	apiURL      string
+	credentials Credentials
+	httpClient  *http.Client
+}
+
+func NewClient(apiURL string, credentials Credentials) *Client {
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	return &Client{
+		apiURL:      strings.TrimRight(apiURL, "/"),
+		credentials: credentials,
+		httpClient:  &http.Client{},
+	}
+}
+
+// WebHost returns the host that serves git and web traffic for an API URL:
+// bitbucket.org for the public API, otherwise the API URL's own host.
+func WebHost(apiURL string) string {
+	if apiURL == "" {
+		return defaultHost
+	}
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host == "" || parsed.Host == "api.bitbucket.org" {
+		return defaultHost
+	}
+	return parsed.Host
+}
+
+// repo is the subset of Bitbucket's repository representation gitall uses.
+type repo struct {
+	Slug        string    `json:"slug"`
+	FullName    string    `json:"full_name"`
+	Description string    `json:"description"`
+	Language    string    `json:"language"`
+	IsPrivate   bool      `json:"is_private"`
+	UpdatedOn   time.Time `json:"updated_on"`
+	Size        int64     `json:"size"` // in bytes
+	HasWiki     bool      `json:"has_wiki"`
+	Parent      *struct {
+		FullName string `json:"full_name"`
+	} `json:"parent"`
+	MainBranch *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Owner struct {
+		Username string `json:"username"`
+		Type     string `json:"type"` // "user" or "team"
+	} `json:"owner"`
+	Links struct {
+		Clone []struct {
+			Name string `json:"name"` // "https" or "ssh"
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+func (r repo) toRepo(workspace string) provider.Repo {
+	visibility := "public"
+	if r.IsPrivate {
+		visibility = "private"
+	}
+
+	ownerType := "User"
+	if r.Owner.Type == "team" {
+		ownerType = "Organization"
+	}
+
+	converted := provider.Repo{
+		Name:        r.Slug,
+		FullName:    r.FullName,
+		Description: r.Description,
+		Language:    r.Language,
+		Visibility:  visibility,
+		Private:     r.IsPrivate,
+		PushedAt:    r.UpdatedOn,
+		Size:        int(r.Size / 1024),
+		Fork:        r.Parent != nil,
+		HasWiki:     r.HasWiki,
+		Owner:       provider.Owner{Login: workspace, Type: ownerType},
+	}
+	if r.MainBranch != nil {
+		converted.DefaultBranch = r.MainBranch.Name
+	}
+	for _, link := range r.Links.Clone {
+		switch link.Name {
+		case "https":
+			converted.CloneURL = stripUserinfo(link.Href)
+		case "ssh":
+			converted.SSHURL = link.Href
+		}
+	}
+	return converted
+}
+
+// stripUserinfo drops the "user@" Bitbucket puts into HTTPS clone links for
+// the authenticated user, so clones use the credential helper instead.
+func stripUserinfo(href string) string {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+type page struct {
+	Values []repo `json:"values"`
+	Next   string `json:"next"`
+}
+
+// ListRepos lists every repo in a workspace, following the next link of
+// each page.
+func (c *Client) ListRepos(workspace string) ([]provider.Repo, error) {
+	pageURL := fmt.Sprintf("%s/repositories/%s?pagelen=100", c.apiURL, url.PathEscape(workspace))
+
+	var all []provider.Repo
+	for pageURL != "" {
+		body, err := c.get(pageURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var p page
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, r := range p.Values {
+			all = append(all, r.toRepo(workspace))
+		}
+
+		pageURL = p.Next
+	}
+	return all, nil
+}
+
+func (c *Client) get(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "gitall-cli")
+	switch {
+	case c.credentials.AppPassword != "":
+		req.SetBasicAuth(c.credentials.Username, c.credentials.AppPassword)
+	case c.credentials.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.credentials.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling Bitbucket API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	return body, nil
+}
+
+func newAPIError(resp *http.Response, url string) *provider.APIError {
+	apiErr := &provider.APIError{
+		Forge:      "Bitbucket",
+		Kind:       provider.KindForStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		URL:        url,
+		TokenVars:  "BITBUCKET_APP_PASSWORD or BITBUCKET_TOKEN",
+		Scopes:     "repository:read",
+	}
+
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &body) == nil {
+		apiErr.Message = body.Error.Message
+	}
+	return apiErr
+}
@@ -0,0 +1,85 @@
+package bitbucket
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func newTestServer(handler http.HandlerFunc) (*httptest.Server, *Client) {
+	server := httptest.NewServer(handler)
+	client := NewClient(server.URL+"/2.0", Credentials{})
+	return server, client
+}
+
+func TestListRepos_FollowsNextLink(t *testing.T) {
+	requestCount := 0
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			fmt.Fprintf(w, `{"values":[{"slug":"legacy-api","is_private":true,"size":2048,"mainbranch":{"name":"master"},
+				"links":{"clone":[{"name":"https","href":"https://jane@bitbucket.org/acme/legacy-api.git"},{"name":"ssh","href":"git@bitbucket.org:acme/legacy-api.git"}]}}],
+				"next":"http://%s/2.0/repositories/acme?pagelen=100&page=2"}`, r.Host)
+			return
+		}
+		fmt.Fprint(w, `{"values":[{"slug":"fork","parent":{"full_name":"other/fork"}}]}`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListRepos("acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requestCount != 2 || len(repos) != 2 {
+		t.Fatalf("expected 2 repos over 2 requests, got %d over %d", len(repos), requestCount)
+	}
+	first := repos[0]
+	if first.Name != "legacy-api" || first.DefaultBranch != "master" || first.Size != 2 || first.RepoVisibility() != "private" {
+		t.Errorf("unexpected first repo %+v", first)
+	}
+	if first.CloneURL != "https://bitbucket.org/acme/legacy-api.git" || first.SSHURL != "git@bitbucket.org:acme/legacy-api.git" {
+		t.Errorf("unexpected clone links %q %q", first.CloneURL, first.SSHURL)
+	}
+	if !repos[1].Fork {
+		t.Errorf("expected second repo to be a fork")
+	}
+}
+
+func TestListRepos_SendsAppPassword(t *testing.T) {
+	var user, password string
+	var ok bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok = r.BasicAuth()
+		fmt.Fprint(w, `{"values":[]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, ParseCredentials("jane:app-secret"))
+	if _, err := client.ListRepos("acme"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ok || user != "jane" || password != "app-secret" {
+		t.Errorf("expected basic auth jane:app-secret, got %q:%q (ok=%v)", user, password, ok)
+	}
+}
+
+func TestListRepos_MapsErrors(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"type":"error","error":{"message":"Workspace not found"}}`)
+	})
+	defer server.Close()
+
+	_, err := client.ListRepos("missing")
+
+	var apiErr *provider.APIError
+	if !errors.Is(err, provider.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Message != "Workspace not found" {
+		t.Errorf("expected not found with message, got %v", err)
+	}
+}
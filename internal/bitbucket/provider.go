@@ -0,0 +1,108 @@
+package bitbucket
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func init() {
+	provider.Register("bitbucket", newAPIProvider)
+}
+
+// apiProvider puts the Bitbucket Cloud client behind provider.Provider. The
+// account's username is the workspace. Responses are not cached, so it
+// cannot serve --offline.
+type apiProvider struct {
+	apiURL  string
+	account provider.Account
+	opts    provider.Options
+}
+
+func newAPIProvider(account provider.Account, opts provider.Options) (provider.Provider, error) {
+	if account.Team != "" {
+		return nil, fmt.Errorf("Bitbucket accounts do not support team listing")
+	}
+	if account.Mode != "" && !strings.EqualFold(account.Mode, "auto") && !strings.EqualFold(account.Mode, "workspace") {
+		return nil, fmt.Errorf("invalid Bitbucket listing mode %q (must be auto or workspace)", account.Mode)
+	}
+
+	apiURL := account.APIURL
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	return &apiProvider{apiURL: apiURL, account: account, opts: opts}, nil
+}
+
+// credentials returns the account's token, falling back to
+// BITBUCKET_USERNAME with BITBUCKET_APP_PASSWORD, then BITBUCKET_TOKEN.
+func (p *apiProvider) credentials() Credentials {
+	if p.account.Token != "" {
+		return ParseCredentials(p.account.Token)
+	}
+	if password := os.Getenv("BITBUCKET_APP_PASSWORD"); password != "" {
+		return Credentials{Username: os.Getenv("BITBUCKET_USERNAME"), AppPassword: password}
+	}
+	return Credentials{Token: os.Getenv("BITBUCKET_TOKEN")}
+}
+
+// Credential reports the secret credentials uses: the configured token, the
+// app password or the access token.
+func (p *apiProvider) Credential() (string, string) {
+	if p.account.Token != "" {
+		return p.account.Token, "config"
+	}
+	for _, env := range []string{"BITBUCKET_APP_PASSWORD", "BITBUCKET_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token, env
+		}
+	}
+	return "", ""
+}
+
+func (p *apiProvider) Name() string {
+	return "bitbucket"
+}
+
+func (p *apiProvider) Host() string {
+	return WebHost(p.apiURL)
+}
+
+func (p *apiProvider) ListRepos(filter provider.Filter) ([]provider.Repo, error) {
+	if p.opts.Offline {
+		return nil, fmt.Errorf("%s: %w — Bitbucket responses are not cached, run again without --offline", p.apiURL, provider.ErrNotCached)
+	}
+
+	repos, err := NewClient(p.apiURL, p.credentials()).ListRepos(p.account.Owner)
+	if err != nil {
+		return nil, err
+	}
+	return provider.FilterRepos(repos, filter), nil
+}
+
+func (p *apiProvider) CloneURL(repo provider.Repo, protocol string) string {
+	switch protocol {
+	case "https":
+		if repo.CloneURL != "" {
+			return repo.CloneURL
+		}
+		return "https://" + p.Host() + "/" + p.account.Owner + "/" + repo.Name + ".git"
+	default:
+		if repo.SSHURL != "" {
+			return repo.SSHURL
+		}
+		return "git@" + p.Host() + ":" + p.account.Owner + "/" + repo.Name + ".git"
+	}
+}
+
+// ParseRemote accepts workspace/slug remotes on the provider's host.
+func (p *apiProvider) ParseRemote(remoteURL string) (git.Remote, bool) {
+	remote, ok := git.ParseRemoteURL(remoteURL)
+	if !ok || !strings.EqualFold(remote.Host, p.Host()) || strings.Contains(remote.Owner, "/") {
+		return git.Remote{}, false
+	}
+	return remote, true
+}
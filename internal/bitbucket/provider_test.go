@@ -0,0 +1,55 @@
+package bitbucket
+
+import (
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func TestProvider_ParseRemoteMatchesHost(t *testing.T) {
+	p, err := provider.New("bitbucket", provider.Account{Owner: "acme"}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Host() != "bitbucket.org" {
+		t.Errorf("expected bitbucket.org, got %q", p.Host())
+	}
+	remote, ok := p.ParseRemote("https://jane@bitbucket.org/acme/legacy-api.git")
+	if !ok || remote.Owner != "acme" || remote.Name != "legacy-api" {
+		t.Errorf("expected acme/legacy-api, got %+v (ok=%v)", remote, ok)
+	}
+	if _, ok := p.ParseRemote("git@github.com:acme/legacy-api.git"); ok {
+		t.Error("expected a github.com remote not to match")
+	}
+	if got := p.CloneURL(provider.Repo{Name: "legacy-api"}, "ssh"); got != "git@bitbucket.org:acme/legacy-api.git" {
+		t.Errorf("unexpected clone URL %q", got)
+	}
+}
+
+func TestParseCredentials(t *testing.T) {
+	if got := ParseCredentials("jane:secret"); got.Username != "jane" || got.AppPassword != "secret" {
+		t.Errorf("expected app password credentials, got %+v", got)
+	}
+	if got := ParseCredentials("access-token"); got.Token != "access-token" {
+		t.Errorf("expected access token credentials, got %+v", got)
+	}
+}
+
+func TestProvider_CredentialReportsSource(t *testing.T) {
+	t.Setenv("BITBUCKET_APP_PASSWORD", "")
+	t.Setenv("BITBUCKET_TOKEN", "bb_token")
+
+	p, err := provider.New("bitbucket", provider.Account{Owner: "acme"}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token, source := p.(provider.CredentialProvider).Credential(); token != "bb_token" || source != "BITBUCKET_TOKEN" {
+		t.Errorf("expected BITBUCKET_TOKEN, got %q from %q", token, source)
+	}
+
+	p, _ = provider.New("bitbucket", provider.Account{Owner: "acme", Token: "me:secret"}, provider.Options{})
+	if _, source := p.(provider.CredentialProvider).Credential(); source != "config" {
+		t.Errorf("expected the config token to win, got %q", source)
+	}
+}
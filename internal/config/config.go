@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/boycook/gitall/internal/layout"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,15 +15,161 @@ var validProtocols = map[string]bool{
 	"https": true,
 }
 
+var validModes = map[string]bool{
+	"":              true,
+	"auto":          true,
+	"user":          true,
+	"org":           true,
+	"team":          true,
+	"authenticated": true,
+}
+
+var validCloneFilters = map[string]bool{
+	"":          true,
+	"blob:none": true,
+	"tree:0":    true,
+}
+
+var validPolicies = map[string]bool{
+	"":        true,
+	"keep":    true,
+	"archive": true,
+	"remove":  true,
+}
+
 type Config struct {
-	Repos []Repo `yaml:"repos,omitempty"`
+	Version  int       `yaml:"version,omitempty"` // schema version, see CurrentVersion
+	Accounts []Account `yaml:"accounts,omitempty"`
+	Repos    []Repo    `yaml:"repos,omitempty"`
+	Sync     Sync      `yaml:"sync,omitempty"`
+	// Layout decides where clone and sync check repos out; it defaults to
+	// {dir}/{name}, one flat directory per account.
+	Layout layout.Layout `yaml:"layout,omitempty"`
+	Backup Backup        `yaml:"backup,omitempty"`
+
+	// Profile is the profile used when none is chosen with --profile or
+	// GITALL_PROFILE. It is only read from the user file.
+	Profile  string             `yaml:"profile,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+
+	// layers are the files of a config loaded with LoadSources. The
+	// shadowed maps hold the entries in them that a later file overrides.
+	layers           []layer
+	shadowedAccounts map[origin]bool
+	shadowedRepos    map[origin]bool
 }
 
-type Repo struct {
-	Name     string `yaml:"name"`
-	Owner    string `yaml:"owner"`
+// Backup configures `gitall backup`: where mirrors are kept, how old a
+// backup may get before it is reported, and whether wikis are mirrored too.
+type Backup struct {
+	Dir    string `yaml:"dir,omitempty"`
+	MaxAge string `yaml:"max_age,omitempty"` // e.g. 36h or 7d
+	Wikis  bool   `yaml:"wikis,omitempty"`
+}
+
+// Sync controls what `gitall sync` does with checkouts whose upstream repo
+// was archived or deleted: keep them, move them to ArchiveDir, or remove
+// them from the config.
+type Sync struct {
+	Archived   string `yaml:"archived,omitempty"`
+	Deleted    string `yaml:"deleted,omitempty"`
+	ArchiveDir string `yaml:"archive_dir,omitempty"`
+}
+
+type Account struct {
+	Username string `yaml:"username"`
 	Dir      string `yaml:"dir"`
-	Protocol string `yaml:"protocol"`
+	Provider string `yaml:"provider,omitempty"` // forge, default github
+	Protocol string `yaml:"protocol,omitempty"`
+	Token    string `yaml:"token,omitempty"`
+	APIURL   string `yaml:"api_url,omitempty"`
+	Mode     string `yaml:"mode,omitempty"` // auto, user, org, team or authenticated
+	Team     string `yaml:"team,omitempty"`
+	Manifest string `yaml:"manifest,omitempty"` // file or URL listing the repos, for provider manifest
+	Active   *bool  `yaml:"active,omitempty"`
+	// Tags are shared by every repo of the account, for --tag selection.
+	Tags []string `yaml:"tags,omitempty"`
+
+	Clone CloneSettings `yaml:"clone,omitempty"`
+
+	origin origin
+}
+
+func (a Account) IsActive() bool {
+	return a.Active == nil || *a.Active
+}
+
+// IsGitHub reports whether the account is on GitHub, the default provider.
+func (a Account) IsGitHub() bool {
+	return a.Provider == "" || strings.EqualFold(a.Provider, "github")
+}
+
+type Repo struct {
+	ID       int64    `yaml:"id,omitempty"` // GitHub repo ID, stable across renames and transfers
+	Name     string   `yaml:"name"`
+	Owner    string   `yaml:"owner"`
+	Provider string   `yaml:"provider,omitempty"` // forge, omitted for github
+	Host     string   `yaml:"host,omitempty"`     // git host, omitted for github.com
+	Dir      string   `yaml:"dir,omitempty"`
+	Protocol string   `yaml:"protocol,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+
+	Clone CloneSettings `yaml:"clone,omitempty"`
+
+	origin    origin
+	inherited inherited
+}
+
+// inherited records which fields of a repo were left out of its file and
+// filled in from its account or the defaults, so Save can leave them out
+// again.
+type inherited struct {
+	dir      bool
+	protocol bool
+}
+
+// CloneSettings are the git clone options for an account or repo. Settings
+// on a repo override those of its account field by field.
+type CloneSettings struct {
+	Depth             int    `yaml:"depth,omitempty"`
+	Filter            string `yaml:"filter,omitempty"` // blob:none or tree:0
+	SingleBranch      bool   `yaml:"single_branch,omitempty"`
+	Branch            string `yaml:"branch,omitempty"`
+	RecurseSubmodules bool   `yaml:"recurse_submodules,omitempty"`
+	Origin            string `yaml:"origin,omitempty"`
+}
+
+func (s CloneSettings) Validate() error {
+	if s.Depth < 0 {
+		return fmt.Errorf("clone depth must not be negative")
+	}
+	if !validCloneFilters[s.Filter] {
+		return fmt.Errorf("invalid clone filter %q (must be blob:none or tree:0)", s.Filter)
+	}
+	return nil
+}
+
+// Merge returns s with every field that is set in override replaced.
+func (s CloneSettings) Merge(override CloneSettings) CloneSettings {
+	if override.Depth != 0 {
+		s.Depth = override.Depth
+	}
+	if override.Filter != "" {
+		s.Filter = override.Filter
+	}
+	if override.SingleBranch {
+		s.SingleBranch = true
+	}
+	if override.Branch != "" {
+		s.Branch = override.Branch
+	}
+	if override.RecurseSubmodules {
+		s.RecurseSubmodules = true
+	}
+	if override.Origin != "" {
+		s.Origin = override.Origin
+	}
+	return s
 }
 
 func DefaultPath() string {
@@ -30,36 +177,237 @@ func DefaultPath() string {
 	return filepath.Join(home, ".gitall", "config.yaml")
 }
 
+// Load reads a config file, migrating older versions in memory. If the
+// file does not exist but a legacy config.json sits next to it, that is
+// read instead; `gitall config migrate` converts it for good.
 func Load(path string) (*Config, error) {
 	expanded := expandPath(path)
-	data, err := os.ReadFile(expanded)
+	data, err := readConfigFile(expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data, filepath.Dir(expanded))
+}
+
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if legacy, legacyErr := os.ReadFile(LegacyPath(path)); legacyErr == nil {
+			data, err = legacy, nil
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("reading config file: %w", err)
 	}
+	return data, nil
+}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parsing config file: %w", err)
+// Parse decodes, migrates and validates config data. Relative dirs and
+// manifest paths are rooted at configDir.
+func Parse(data []byte, configDir string) (*Config, error) {
+	cfg, err := decode(data, configDir)
+	if err != nil {
+		return nil, err
 	}
 
 	for i := range cfg.Repos {
-		cfg.Repos[i].Dir = expandPath(cfg.Repos[i].Dir)
-
-		if cfg.Repos[i].Protocol == "" {
-			cfg.Repos[i].Protocol = "ssh"
-		}
+		cfg.resolveRepo(&cfg.Repos[i], configDir)
 	}
 
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
-	return &cfg, nil
+	return cfg, nil
+}
+
+// decode migrates and unmarshals config data and resolves its accounts and
+// settings. Repos are left for resolveRepo, as they may inherit from
+// accounts in another file.
+func decode(data []byte, configDir string) (*Config, error) {
+	cfg, _, err := unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg.resolvePaths(configDir)
+	return cfg, nil
+}
+
+// unmarshal migrates and unmarshals config data as written in the file. It
+// also returns the migrated data.
+func unmarshal(data []byte) (*Config, []byte, error) {
+	migrated, err := Migrate(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(migrated.Data, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("parsing config file: %w", err)
+	}
+	cfg.Version = CurrentVersion
+	return &cfg, migrated.Data, nil
+}
+
+// resolvePaths expands the dirs of accounts, repos and settings and fills in
+// account defaults.
+func (c *Config) resolvePaths(configDir string) {
+	for i := range c.Accounts {
+		c.Accounts[i].Dir = resolveDir(c.Accounts[i].Dir, configDir)
+		c.Accounts[i].Manifest = resolveManifest(c.Accounts[i].Manifest, configDir)
+		if c.Accounts[i].Manifest != "" && c.Accounts[i].Provider == "" {
+			c.Accounts[i].Provider = "manifest"
+		}
+
+		if c.Accounts[i].Protocol == "" {
+			c.Accounts[i].Protocol = "ssh"
+		}
+	}
+
+	c.Sync.ArchiveDir = expandPath(c.Sync.ArchiveDir)
+	c.Layout.Root = expandPath(c.Layout.Root)
+	c.Backup.Dir = expandPath(c.Backup.Dir)
+
+	for i := range c.Repos {
+		c.Repos[i].Dir = expandPath(c.Repos[i].Dir)
+	}
+}
+
+// resolveRepo fills in a repo from its account and roots a relative dir
+// that no account claims at configDir.
+func (c *Config) resolveRepo(repo *Repo, configDir string) {
+	repo.inherited = inherited{dir: repo.Dir == "", protocol: repo.Protocol == ""}
+	c.inheritFromAccount(repo)
+	repo.Dir = resolveDir(repo.Dir, configDir)
+	if repo.Protocol == "" {
+		repo.Protocol = "ssh"
+	}
+}
+
+// savedRepo returns repo as Save writes it: without the fields it
+// inherited, as long as leaving them out still resolves to the same values.
+// A repo whose dir was changed since loading keeps the new dir.
+func (c *Config) savedRepo(repo Repo, configDir string) Repo {
+	probe := repo
+	if repo.inherited.dir {
+		probe.Dir = ""
+	}
+	if repo.inherited.protocol {
+		probe.Protocol = ""
+	}
+	c.resolveRepo(&probe, configDir)
+	if repo.inherited.dir && probe.Dir == repo.Dir {
+		repo.Dir = ""
+	}
+	if repo.inherited.protocol && probe.Protocol == repo.Protocol {
+		repo.Protocol = ""
+	}
+	return repo
+}
+
+// resolveDir expands a dir from a config file, rooting relative dirs at
+// the file's directory.
+func resolveDir(dir, configDir string) string {
+	dir = expandPath(dir)
+	if dir != "" && configDir != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(configDir, dir)
+	}
+	return dir
+}
+
+// resolveManifest expands a manifest file path, rooting relative paths at
+// the config file's directory so a workspace definition can sit next to it.
+// URLs are left alone.
+func resolveManifest(source, configDir string) string {
+	if source == "" || strings.Contains(source, "://") {
+		return source
+	}
+	source = expandPath(source)
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(configDir, source)
+	}
+	return source
+}
+
+// inheritFromAccount fills in a repo's protocol and directory from the
+// account matching its owner. Relative repo dirs are rooted at the account dir.
+func (c *Config) inheritFromAccount(repo *Repo) {
+	account := c.FindAccount(repo.Owner)
+	if account == nil {
+		return
+	}
+
+	if repo.Protocol == "" {
+		repo.Protocol = account.Protocol
+	}
+	if repo.Dir == "" && repo.Name != "" {
+		repo.Dir = filepath.Join(account.Dir, repo.Name)
+	} else if repo.Dir != "" && !filepath.IsAbs(repo.Dir) {
+		repo.Dir = filepath.Join(account.Dir, repo.Dir)
+	}
 }
 
 func (c *Config) Validate() error {
-	if len(c.Repos) == 0 {
-		return fmt.Errorf("config must contain at least one repo")
+	if len(c.Accounts) == 0 && len(c.Repos) == 0 && len(c.Profiles) == 0 && c.Profile == "" {
+		return fmt.Errorf("config must contain at least one account or repo")
+	}
+	return c.validateEntries()
+}
+
+// validateEntries checks the accounts, repos and settings of a config. A
+// file in a layered config may have no entries of its own.
+func (c *Config) validateEntries() error {
+	seen := map[string]bool{}
+	for i, account := range c.Accounts {
+		if account.Username == "" {
+			return fmt.Errorf("account %d: username is required", i+1)
+		}
+		if account.Dir == "" {
+			return fmt.Errorf("account %d (%s): dir is required", i+1, account.Username)
+		}
+		if !validProtocols[account.Protocol] {
+			return fmt.Errorf("account %d (%s): invalid protocol %q (must be ssh or https)", i+1, account.Username, account.Protocol)
+		}
+		// Other providers validate their own listing modes.
+		if account.IsGitHub() && !validModes[account.Mode] {
+			return fmt.Errorf("account %d (%s): invalid mode %q (must be auto, user, org, team or authenticated)", i+1, account.Username, account.Mode)
+		}
+		if account.Mode == "team" && account.Team == "" {
+			return fmt.Errorf("account %d (%s): team is required when mode is team", i+1, account.Username)
+		}
+		if err := account.Clone.Validate(); err != nil {
+			return fmt.Errorf("account %d (%s): %w", i+1, account.Username, err)
+		}
+		if strings.EqualFold(account.Provider, "manifest") && account.Manifest == "" {
+			return fmt.Errorf("account %d (%s): manifest is required for provider manifest", i+1, account.Username)
+		}
+		if err := validateTags(account.Tags); err != nil {
+			return fmt.Errorf("account %d (%s): %w", i+1, account.Username, err)
+		}
+
+		key := strings.ToLower(account.Username)
+		if seen[key] {
+			return fmt.Errorf("account %d (%s): duplicate username", i+1, account.Username)
+		}
+		seen[key] = true
+	}
+
+	if !validPolicies[c.Sync.Archived] {
+		return fmt.Errorf("sync: invalid archived policy %q (must be keep, archive or remove)", c.Sync.Archived)
+	}
+	if !validPolicies[c.Sync.Deleted] {
+		return fmt.Errorf("sync: invalid deleted policy %q (must be keep, archive or remove)", c.Sync.Deleted)
+	}
+
+	if err := c.Layout.Validate(); err != nil {
+		return err
+	}
+
+	for name := range c.Profiles {
+		if err := validateProfileName(name); err != nil {
+			return err
+		}
 	}
 
 	for i, repo := range c.Repos {
@@ -72,6 +420,12 @@ func (c *Config) Validate() error {
 		if !validProtocols[repo.Protocol] {
 			return fmt.Errorf("repo %d (%s): invalid protocol %q (must be ssh or https)", i+1, repo.Name, repo.Protocol)
 		}
+		if err := repo.Clone.Validate(); err != nil {
+			return fmt.Errorf("repo %d (%s): %w", i+1, repo.Name, err)
+		}
+		if err := validateTags(repo.Tags); err != nil {
+			return fmt.Errorf("repo %d (%s): %w", i+1, repo.Name, err)
+		}
 	}
 
 	return nil
@@ -81,6 +435,53 @@ func (c *Config) HasRepos() bool {
 	return len(c.Repos) > 0
 }
 
+func (c *Config) HasAccounts() bool {
+	return len(c.Accounts) > 0
+}
+
+func (c *Config) ActiveAccounts() []Account {
+	var active []Account
+	for _, account := range c.Accounts {
+		if account.IsActive() {
+			active = append(active, account)
+		}
+	}
+	return active
+}
+
+// FindAccount returns the account for username. A nested group path such as
+// "acme/platform" falls back to the account of its nearest parent group.
+func (c *Config) FindAccount(username string) *Account {
+	if username == "" {
+		return nil
+	}
+	for i := range c.Accounts {
+		if strings.EqualFold(c.Accounts[i].Username, username) {
+			return &c.Accounts[i]
+		}
+	}
+	if idx := strings.LastIndex(username, "/"); idx > 0 {
+		return c.FindAccount(username[:idx])
+	}
+	return nil
+}
+
+// OwnedBy reports whether owner is username or a group nested below it, as
+// with GitLab subgroups: "acme/platform" is owned by "acme".
+func OwnedBy(owner, username string) bool {
+	if strings.EqualFold(owner, username) {
+		return true
+	}
+	return len(owner) > len(username) && owner[len(username)] == '/' && strings.EqualFold(owner[:len(username)], username)
+}
+
+// IsOwnerActive reports whether repos for owner should be acted on. Owners
+// without an account entry are always active.
+func (c *Config) IsOwnerActive(owner string) bool {
+	account := c.FindAccount(owner)
+	return account == nil || account.IsActive()
+}
+
 func (c *Config) RepoDirs() []string {
 	dirs := make([]string, len(c.Repos))
 	for i, repo := range c.Repos {
@@ -112,32 +513,121 @@ func (c *Config) AddRepo(repo Repo) error {
 	return nil
 }
 
+func (c *Config) AddAccount(account Account) error {
+	for _, existing := range c.Accounts {
+		if strings.EqualFold(existing.Username, account.Username) {
+			return fmt.Errorf("account %q already exists", account.Username)
+		}
+	}
+	c.Accounts = append(c.Accounts, account)
+	return nil
+}
+
+// RemoveMatching drops the accounts and repos target refers to and returns
+// them. An absolute path matches repos by dir; anything else matches
+// accounts by username and repos by name, owner/name or owner, including
+// groups nested below the owner.
+func (c *Config) RemoveMatching(target string) ([]Account, []Repo) {
+	var removedAccounts []Account
+	var removedRepos []Repo
+
+	if !filepath.IsAbs(target) {
+		var keptAccounts []Account
+		for _, account := range c.Accounts {
+			if strings.EqualFold(account.Username, target) {
+				removedAccounts = append(removedAccounts, account)
+			} else {
+				keptAccounts = append(keptAccounts, account)
+			}
+		}
+		c.Accounts = keptAccounts
+	}
+
+	var keptRepos []Repo
+	for _, repo := range c.Repos {
+		if repo.matches(target) {
+			removedRepos = append(removedRepos, repo)
+		} else {
+			keptRepos = append(keptRepos, repo)
+		}
+	}
+	c.Repos = keptRepos
+
+	return removedAccounts, removedRepos
+}
+
+func (r Repo) matches(target string) bool {
+	if filepath.IsAbs(target) {
+		return filepath.Clean(r.Dir) == filepath.Clean(target)
+	}
+	return strings.EqualFold(r.Name, target) ||
+		strings.EqualFold(r.Owner+"/"+r.Name, target) ||
+		OwnedBy(r.Owner, target)
+}
+
+func (c *Config) FindRepoByDir(dir string) *Repo {
+	for i := range c.Repos {
+		if c.Repos[i].Dir == dir {
+			return &c.Repos[i]
+		}
+	}
+	return nil
+}
+
+func (c *Config) RemoveRepoByDir(dir string) bool {
+	for i, repo := range c.Repos {
+		if repo.Dir == dir {
+			c.Repos = append(c.Repos[:i], c.Repos[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func DefaultConfig() *Config {
 	home, _ := os.UserHomeDir()
 	return &Config{
-		Repos: []Repo{
+		Version: CurrentVersion,
+		Accounts: []Account{
 			{
-				Name:     "example-repo",
-				Owner:    "your-github-username",
-				Dir:      filepath.Join(home, "code", "example-repo"),
+				Username: "your-github-username",
+				Dir:      filepath.Join(home, "code", "your-github-username"),
 				Protocol: "ssh",
 			},
 		},
 	}
 }
 
+// Save writes cfg to path, stamped with the current version. For a layered
+// config only the entries loaded from path, and any added since, are
+// written; the other files are left alone.
 func Save(cfg *Config, path string) error {
 	expanded := expandPath(path)
 
-	dir := filepath.Dir(expanded)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating config directory: %w", err)
+	var data []byte
+	var err error
+	if cfg.layers != nil {
+		data, err = cfg.marshalFile(expanded)
+	} else {
+		cfg.Version = CurrentVersion
+		out := *cfg
+		out.Repos = make([]Repo, len(cfg.Repos))
+		for i, repo := range cfg.Repos {
+			out.Repos[i] = cfg.savedRepo(repo, filepath.Dir(expanded))
+		}
+		data, err = yaml.Marshal(&out)
 	}
-
-	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("marshalling config: %w", err)
 	}
+	if data == nil {
+		return nil
+	}
+
+	dir := filepath.Dir(expanded)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
 
 	if err := os.WriteFile(expanded, data, 0o644); err != nil {
 		return fmt.Errorf("writing config file: %w", err)
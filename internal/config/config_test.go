@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -302,3 +303,419 @@ func TestExpandPath_AbsolutePathUnchanged(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, result)
 	}
 }
+
+func TestLoad_AccountsOnly(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: BoyCook
+    dir: /tmp/code/boycook
+  - username: SomeOrg
+    dir: /tmp/code/org
+    protocol: https
+    token: ghp_xxx
+    api_url: https://ghe.example.com/api/v3
+    active: false
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedCount := 2
+	if len(cfg.Accounts) != expectedCount {
+		t.Fatalf("expected %d accounts, got %d", expectedCount, len(cfg.Accounts))
+	}
+
+	expectedProtocol := "ssh"
+	if cfg.Accounts[0].Protocol != expectedProtocol {
+		t.Errorf("expected default protocol %q, got %q", expectedProtocol, cfg.Accounts[0].Protocol)
+	}
+
+	expectedAPIURL := "https://ghe.example.com/api/v3"
+	if cfg.Accounts[1].APIURL != expectedAPIURL {
+		t.Errorf("expected api_url %q, got %q", expectedAPIURL, cfg.Accounts[1].APIURL)
+	}
+
+	if cfg.Accounts[1].IsActive() {
+		t.Error("expected second account to be inactive")
+	}
+}
+
+func TestLoad_AccountExpandsTildePath(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	path := writeTestConfig(t, `
+accounts:
+  - username: BoyCook
+    dir: ~/code/boycook
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := filepath.Join(home, "code/boycook")
+	if cfg.Accounts[0].Dir != expected {
+		t.Errorf("expected dir %q, got %q", expected, cfg.Accounts[0].Dir)
+	}
+}
+
+func TestLoad_RepoInheritsFromAccount(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: SomeOrg
+    dir: /tmp/code/org
+    protocol: https
+repos:
+  - name: api
+    owner: someorg
+  - name: web
+    owner: SomeOrg
+    dir: frontend/web
+  - name: tools
+    owner: SomeOrg
+    dir: /opt/tools
+    protocol: ssh
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedDir := "/tmp/code/org/api"
+	if cfg.Repos[0].Dir != expectedDir {
+		t.Errorf("expected dir %q, got %q", expectedDir, cfg.Repos[0].Dir)
+	}
+
+	expectedProtocol := "https"
+	if cfg.Repos[0].Protocol != expectedProtocol {
+		t.Errorf("expected protocol %q, got %q", expectedProtocol, cfg.Repos[0].Protocol)
+	}
+
+	expectedRelativeDir := "/tmp/code/org/frontend/web"
+	if cfg.Repos[1].Dir != expectedRelativeDir {
+		t.Errorf("expected dir %q, got %q", expectedRelativeDir, cfg.Repos[1].Dir)
+	}
+
+	expectedOverride := "ssh"
+	if cfg.Repos[2].Protocol != expectedOverride {
+		t.Errorf("expected protocol %q, got %q", expectedOverride, cfg.Repos[2].Protocol)
+	}
+}
+
+func TestValidate_AccountMissingUsername(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Dir: "/tmp/code", Protocol: "ssh"}},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for missing username, got nil")
+	}
+}
+
+func TestValidate_AccountMissingDir(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Username: "user", Protocol: "ssh"}},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for missing account dir, got nil")
+	}
+}
+
+func TestValidate_AccountInvalidMode(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Username: "user", Dir: "/tmp/code", Protocol: "ssh", Mode: "enterprise"}},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for invalid mode, got nil")
+	}
+}
+
+func TestValidate_DuplicateAccounts(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{
+			{Username: "User", Dir: "/tmp/a", Protocol: "ssh"},
+			{Username: "user", Dir: "/tmp/b", Protocol: "ssh"},
+		},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for duplicate accounts, got nil")
+	}
+}
+
+func TestActiveAccounts_SkipsInactive(t *testing.T) {
+	inactive := false
+	cfg := &Config{
+		Accounts: []Account{
+			{Username: "on", Dir: "/tmp/on", Protocol: "ssh"},
+			{Username: "off", Dir: "/tmp/off", Protocol: "ssh", Active: &inactive},
+		},
+	}
+
+	active := cfg.ActiveAccounts()
+
+	expectedCount := 1
+	if len(active) != expectedCount {
+		t.Fatalf("expected %d active account, got %d", expectedCount, len(active))
+	}
+
+	if cfg.IsOwnerActive("OFF") {
+		t.Error("expected owner of inactive account to be inactive")
+	}
+	if !cfg.IsOwnerActive("unconfigured") {
+		t.Error("expected owner without account to be active")
+	}
+}
+
+func TestValidate_LeavesOtherProviderModesToTheProvider(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{
+			{Username: "acme", Dir: "/tmp/acme", Protocol: "ssh", Provider: "gitlab", Mode: "group"},
+			{Username: "me", Dir: "/tmp/me", Protocol: "ssh", Mode: "group"},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "account 2") {
+		t.Fatalf("expected only the GitHub account's mode to be rejected, got %v", err)
+	}
+}
+
+func TestFindAccount_FallsBackToParentGroup(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Username: "acme", Dir: "/tmp/acme", Protocol: "ssh", Provider: "gitlab"}},
+	}
+
+	account := cfg.FindAccount("Acme/platform/backend")
+	if account == nil || account.Username != "acme" {
+		t.Fatalf("expected acme account, got %+v", account)
+	}
+	if cfg.FindAccount("acmecorp/platform") != nil {
+		t.Error("expected no account for a different group")
+	}
+
+	if !OwnedBy("acme/platform", "ACME") || OwnedBy("acmecorp", "acme") {
+		t.Error("expected OwnedBy to match nested groups only")
+	}
+}
+
+func TestValidate_InvalidSyncPolicy(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Username: "user", Dir: "/tmp/code", Protocol: "ssh"}},
+		Sync:     Sync{Archived: "delete"},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for invalid sync policy, got nil")
+	}
+}
+
+func TestRemoveRepoByDir_RemovesMatchingRepo(t *testing.T) {
+	cfg := &Config{
+		Repos: []Repo{
+			{Name: "repo1", Dir: "/tmp/repo1", Protocol: "ssh"},
+			{Name: "repo2", Dir: "/tmp/repo2", Protocol: "ssh"},
+		},
+	}
+
+	if !cfg.RemoveRepoByDir("/tmp/repo1") {
+		t.Fatal("expected repo to be removed")
+	}
+
+	expectedCount := 1
+	if len(cfg.Repos) != expectedCount {
+		t.Fatalf("expected %d repo, got %d", expectedCount, len(cfg.Repos))
+	}
+
+	if cfg.RemoveRepoByDir("/tmp/missing") {
+		t.Error("expected no removal for unknown dir")
+	}
+}
+
+func TestAddAccount_RejectsDuplicateUsername(t *testing.T) {
+	cfg := &Config{Accounts: []Account{{Username: "acme", Dir: "/tmp/acme"}}}
+
+	if err := cfg.AddAccount(Account{Username: "acme/platform", Dir: "/tmp/platform"}); err != nil {
+		t.Fatalf("expected a subgroup account to be added, got %v", err)
+	}
+	if err := cfg.AddAccount(Account{Username: "ACME", Dir: "/tmp/other"}); err == nil {
+		t.Fatal("expected error for duplicate username, got nil")
+	}
+}
+
+func TestRemoveMatching_ByNameOwnerAndPath(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Accounts: []Account{{Username: "acme", Dir: "/code/acme"}},
+			Repos: []Repo{
+				{Name: "api", Owner: "acme", Dir: "/code/acme/api"},
+				{Name: "tools", Owner: "acme/platform", Dir: "/code/acme/platform/tools"},
+				{Name: "api", Owner: "jane", Dir: "/code/jane/api"},
+			},
+		}
+	}
+
+	tests := []struct {
+		target   string
+		accounts int
+		repos    []string
+	}{
+		{"api", 0, []string{"/code/acme/api", "/code/jane/api"}},
+		{"jane/api", 0, []string{"/code/jane/api"}},
+		{"acme", 1, []string{"/code/acme/api", "/code/acme/platform/tools"}},
+		{"/code/acme/platform/tools/", 0, []string{"/code/acme/platform/tools"}},
+		{"missing", 0, nil},
+	}
+
+	for _, tt := range tests {
+		cfg := newConfig()
+		accounts, repos := cfg.RemoveMatching(tt.target)
+
+		var dirs []string
+		for _, repo := range repos {
+			dirs = append(dirs, repo.Dir)
+		}
+		if len(accounts) != tt.accounts || strings.Join(dirs, ",") != strings.Join(tt.repos, ",") {
+			t.Errorf("%s: expected %d account(s) and repos %v, got %d and %v", tt.target, tt.accounts, tt.repos, len(accounts), dirs)
+		}
+		if len(cfg.Accounts)+len(accounts) != 1 || len(cfg.Repos)+len(repos) != 3 {
+			t.Errorf("%s: removed entries are still in the config", tt.target)
+		}
+	}
+}
+
+func TestFindRepoByDir_ReturnsPointerIntoConfig(t *testing.T) {
+	cfg := &Config{
+		Repos: []Repo{{Name: "repo1", Owner: "old", Dir: "/tmp/repo1", Protocol: "ssh"}},
+	}
+
+	repo := cfg.FindRepoByDir("/tmp/repo1")
+	if repo == nil {
+		t.Fatal("expected repo to be found")
+	}
+	repo.Owner = "new"
+
+	expectedOwner := "new"
+	if cfg.Repos[0].Owner != expectedOwner {
+		t.Errorf("expected owner %q, got %q", expectedOwner, cfg.Repos[0].Owner)
+	}
+
+	if cfg.FindRepoByDir("/tmp/missing") != nil {
+		t.Error("expected nil for unknown dir")
+	}
+}
+
+func TestLoad_CloneSettings(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: myorg
+    dir: /tmp/myorg
+    clone:
+      filter: blob:none
+      single_branch: true
+repos:
+  - name: big-repo
+    owner: myorg
+    clone:
+      depth: 1
+      branch: release
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	merged := cfg.Accounts[0].Clone.Merge(cfg.Repos[0].Clone)
+	expected := CloneSettings{Depth: 1, Filter: "blob:none", SingleBranch: true, Branch: "release"}
+	if merged != expected {
+		t.Errorf("expected merged settings %+v, got %+v", expected, merged)
+	}
+}
+
+func TestValidate_InvalidCloneFilter(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Username: "myorg", Dir: "/tmp/myorg", Protocol: "ssh", Clone: CloneSettings{Filter: "blob:all"}}},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for invalid clone filter, got nil")
+	}
+}
+
+func TestLoad_LayoutExpandsRoot(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: me
+    dir: /tmp/me
+layout:
+  template: "{root}/{host}/{owner}/{name}"
+  root: ~/src
+  lowercase: true
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	home, _ := os.UserHomeDir()
+	expectedRoot := filepath.Join(home, "src")
+	if cfg.Layout.Root != expectedRoot {
+		t.Errorf("expected root %q, got %q", expectedRoot, cfg.Layout.Root)
+	}
+	if !cfg.Layout.Lowercase {
+		t.Error("expected lowercase to be set")
+	}
+}
+
+func TestValidate_LayoutRequiresRoot(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Username: "me", Dir: "/tmp/me", Protocol: "ssh"}},
+	}
+	cfg.Layout.Template = "{root}/{owner}/{name}"
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for layout without root, got nil")
+	}
+}
+
+func TestLoad_ManifestAccount(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: team
+    dir: /tmp/team
+    manifest: workspace.yaml
+  - username: remote
+    dir: /tmp/remote
+    manifest: https://example.com/workspace.yaml
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedManifest := filepath.Join(filepath.Dir(path), "workspace.yaml")
+	if cfg.Accounts[0].Manifest != expectedManifest || cfg.Accounts[0].Provider != "manifest" {
+		t.Errorf("expected manifest %q with provider manifest, got %+v", expectedManifest, cfg.Accounts[0])
+	}
+	if cfg.Accounts[1].Manifest != "https://example.com/workspace.yaml" {
+		t.Errorf("expected URL to be left alone, got %q", cfg.Accounts[1].Manifest)
+	}
+}
+
+func TestValidate_ManifestProviderNeedsManifest(t *testing.T) {
+	cfg := &Config{
+		Accounts: []Account{{Username: "team", Dir: "/tmp/team", Protocol: "ssh", Provider: "manifest"}},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for manifest account without manifest, got nil")
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import "strings"
+
+// DiffLine is one line of a Diff. Op is ' ' for a line both sides share,
+// '-' for a removed line and '+' for an added one.
+type DiffLine struct {
+	Op   byte
+	Text string
+}
+
+// Diff compares two versions of a file line by line, using the longest
+// common subsequence. Config files are small enough for the quadratic table.
+func Diff(before, after string) []DiffLine {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// common[i][j] is the LCS length of a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, DiffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, DiffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,462 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/boycook/gitall/internal/layout"
+	"gopkg.in/yaml.v3"
+)
+
+// Config scopes, from lowest to highest precedence. ScopeFile is a config
+// named with --config or GITALL_CONFIG, which is loaded on its own.
+const (
+	ScopeSystem  = "system"
+	ScopeUser    = "user"
+	ScopeProject = "project"
+	ScopeFile    = "file"
+)
+
+// ProjectFile is the name of a project config, found by walking up from the
+// current directory. A team can commit one to a meta-repo to share a
+// workspace definition.
+const ProjectFile = ".gitall.yaml"
+
+// Source is one config file in a layered config. Profile names an entry of
+// the file's profiles: section to read instead of its own accounts and repos.
+type Source struct {
+	Scope   string `json:"scope"`
+	Path    string `json:"path"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// origin records which file an account or repo was loaded from, and its
+// position there, so that Save can write changes back to the right entry.
+type origin struct {
+	path  string
+	index int
+}
+
+// layer is one loaded file of a layered config. raw holds its accounts and
+// repos as written, before dirs and defaults were resolved, and data is the
+// file as read, which Save edits so that the rest of the file is kept.
+type layer struct {
+	Source
+	cfg  *Config
+	raw  *Config
+	data []byte
+}
+
+// SystemPath returns the machine-wide config file.
+func SystemPath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), "gitall", "config.yaml")
+	}
+	return "/etc/gitall/config.yaml"
+}
+
+// FindProjectFile walks up from dir to the filesystem root looking for a
+// project config. It returns "" if there is none.
+func FindProjectFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Sources lists the config files that apply in dir, lowest precedence
+// first: the system file, then user, then the nearest project file. user is the
+// user file or the profile that takes its place. Files that do not exist
+// are left for LoadSources to skip.
+func Sources(dir string, user Source) []Source {
+	sources := []Source{
+		{Scope: ScopeSystem, Path: SystemPath()},
+		user,
+	}
+	if project := FindProjectFile(dir); project != "" {
+		sources = append(sources, Source{Scope: ScopeProject, Path: project})
+	}
+	return sources
+}
+
+// LoadSources loads and merges config files, skipping those that do not
+// exist. Later files take precedence: an account replaces one with the same
+// username, a repo replaces one with the same dir, and a layout, sync or
+// backup section replaces the whole section. Relative dirs in each file
+// are rooted at that file's directory, but repos inherit from accounts in
+// any file.
+func LoadSources(sources []Source) (*Config, error) {
+	var layers []layer
+	for _, source := range sources {
+		expanded := expandPath(source.Path)
+		data, err := readConfigFile(expanded)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		file, data, err := unmarshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", expanded, err)
+		}
+		cfg := file
+		if source.Profile != "" {
+			if cfg, err = file.withProfile(source.Profile); err != nil {
+				return nil, fmt.Errorf("%s: %w", expanded, err)
+			}
+		}
+		raw := &Config{Accounts: slices.Clone(cfg.Accounts), Repos: slices.Clone(cfg.Repos)}
+		cfg.resolvePaths(filepath.Dir(expanded))
+		source.Path = expanded
+		layers = append(layers, layer{source, cfg, raw, data})
+	}
+	if len(layers) == 0 {
+		paths := make([]string, len(sources))
+		for i, source := range sources {
+			paths[i] = source.Path
+		}
+		return nil, fmt.Errorf("reading config file: none of %s: %w", strings.Join(paths, ", "), fs.ErrNotExist)
+	}
+
+	merged := &Config{Version: CurrentVersion, layers: layers, shadowedAccounts: map[origin]bool{}, shadowedRepos: map[origin]bool{}}
+	for _, l := range layers {
+		for i, account := range l.cfg.Accounts {
+			account.origin = origin{l.Path, i}
+			merged.mergeAccount(account)
+		}
+		if l.cfg.Layout != (layout.Layout{}) {
+			merged.Layout = l.cfg.Layout
+		}
+		if l.cfg.Sync != (Sync{}) {
+			merged.Sync = l.cfg.Sync
+		}
+		if l.cfg.Backup != (Backup{}) {
+			merged.Backup = l.cfg.Backup
+		}
+	}
+
+	// Repos are resolved once every account is known, so a project file can
+	// list repos for an account in the user file.
+	for _, l := range layers {
+		for i := range l.cfg.Repos {
+			merged.resolveRepo(&l.cfg.Repos[i], filepath.Dir(l.Path))
+		}
+		if err := l.cfg.validateEntries(); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.Path, err)
+		}
+		for i, repo := range l.cfg.Repos {
+			repo.origin = origin{l.Path, i}
+			merged.mergeRepo(repo)
+		}
+	}
+
+	if !merged.HasAccounts() && !merged.HasRepos() {
+		for _, l := range layers {
+			if len(l.cfg.Profiles) > 0 {
+				return nil, fmt.Errorf("%s only has accounts and repos in profiles — choose one with --profile or 'gitall profile use'", l.Path)
+			}
+		}
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func (c *Config) mergeAccount(account Account) {
+	for i := range c.Accounts {
+		if strings.EqualFold(c.Accounts[i].Username, account.Username) {
+			c.shadowedAccounts[c.Accounts[i].origin] = true
+			c.Accounts[i] = account
+			return
+		}
+	}
+	c.Accounts = append(c.Accounts, account)
+}
+
+func (c *Config) mergeRepo(repo Repo) {
+	for i := range c.Repos {
+		if filepath.Clean(c.Repos[i].Dir) == filepath.Clean(repo.Dir) {
+			c.shadowedRepos[c.Repos[i].origin] = true
+			c.Repos[i] = repo
+			return
+		}
+	}
+	c.Repos = append(c.Repos, repo)
+}
+
+// Sources returns the files a layered config was merged from, lowest
+// precedence first. It is empty for a config loaded with Load.
+func (c *Config) Sources() []Source {
+	sources := make([]Source, len(c.layers))
+	for i, l := range c.layers {
+		sources[i] = l.Source
+	}
+	return sources
+}
+
+// Origin returns the file the account was loaded from, or "" if it was
+// added since.
+func (a Account) Origin() string {
+	return a.origin.path
+}
+
+// Origin returns the file the repo was loaded from, or "" if it was added
+// since.
+func (r Repo) Origin() string {
+	return r.origin.path
+}
+
+// marshalFile rebuilds one file of a layered config: its own entries as
+// changed since loading, minus those removed, plus any new entries.
+// Unchanged entries, and those overridden by a later file, are kept as
+// written; changed ones keep the form they were written in for the fields
+// that still hold what was loaded. A profile's entries go back into its
+// profiles: entry, and the rest of the file is kept as it was. It returns
+// nil when nothing in the file changed.
+func (c *Config) marshalFile(path string) ([]byte, error) {
+	var accounts, repos []entry
+	var source *layer
+	for n, l := range c.layers {
+		if l.Path != path {
+			continue
+		}
+		source = &c.layers[n]
+		for i, account := range l.cfg.Accounts {
+			o := origin{path, i}
+			current := c.findAccountByOrigin(o)
+			switch {
+			case current != nil && !sameAccount(*current, account):
+				accounts = append(accounts, entry{-1, unresolveAccount(*current, account, l.raw.Accounts[i])})
+			case current != nil || c.shadowedAccounts[o]:
+				accounts = append(accounts, entry{index: i})
+			}
+		}
+		for i, repo := range l.cfg.Repos {
+			o := origin{path, i}
+			current := c.findRepoByOrigin(o)
+			switch {
+			case current != nil && !sameRepo(*current, repo):
+				repos = append(repos, entry{-1, c.unresolveRepo(*current, repo, l.raw.Repos[i], filepath.Dir(path))})
+			case current != nil || c.shadowedRepos[o]:
+				repos = append(repos, entry{index: i})
+			}
+		}
+	}
+
+	var newAccounts []Account
+	var newRepos []Repo
+	for _, account := range c.Accounts {
+		if account.origin.path == "" {
+			newAccounts = append(newAccounts, account)
+			accounts = append(accounts, entry{-1, account})
+		}
+	}
+	for _, repo := range c.Repos {
+		if repo.origin.path == "" {
+			repo = c.savedRepo(repo, filepath.Dir(path))
+			newRepos = append(newRepos, repo)
+			repos = append(repos, entry{-1, repo})
+		}
+	}
+
+	switch {
+	case source == nil && len(newAccounts) == 0 && len(newRepos) == 0:
+		return nil, nil
+	case source == nil:
+		return yaml.Marshal(&Config{Version: CurrentVersion, Accounts: newAccounts, Repos: newRepos})
+	}
+	return replaceEntries(source.data, source.Profile, accounts, repos)
+}
+
+// entry is an account or repo to write to a file: the index of its node in
+// the file if it is unchanged, or else -1 and the value to encode.
+type entry struct {
+	index int
+	value any
+}
+
+// sameAccount reports whether current is the account as loaded.
+func sameAccount(current, loaded Account) bool {
+	current.origin = loaded.origin
+	return reflect.DeepEqual(current, loaded)
+}
+
+// sameRepo reports whether current is the repo as loaded.
+func sameRepo(current, loaded Repo) bool {
+	current.origin = loaded.origin
+	return reflect.DeepEqual(current, loaded)
+}
+
+// unresolveAccount returns a changed account as it goes back in its file,
+// with the fields that still hold what was loaded as they were written.
+func unresolveAccount(current, loaded, raw Account) Account {
+	if current.Dir == loaded.Dir {
+		current.Dir = raw.Dir
+	}
+	if current.Protocol == loaded.Protocol {
+		current.Protocol = raw.Protocol
+	}
+	if current.Provider == loaded.Provider {
+		current.Provider = raw.Provider
+	}
+	if current.Manifest == loaded.Manifest {
+		current.Manifest = raw.Manifest
+	}
+	return current
+}
+
+// unresolveRepo returns a changed repo as it goes back in its file, with
+// the fields that still hold what was loaded as they were written.
+func (c *Config) unresolveRepo(current, loaded, raw Repo, configDir string) Repo {
+	repo := c.savedRepo(current, configDir)
+	if current.Dir == loaded.Dir {
+		repo.Dir = raw.Dir
+	}
+	if current.Protocol == loaded.Protocol {
+		repo.Protocol = raw.Protocol
+	}
+	return repo
+}
+
+// replaceEntries replaces the accounts: and repos: of config data, or of
+// one of its profiles, keeping the rest of the file as written. It returns
+// nil if both lists are unchanged.
+func replaceEntries(data []byte, profile string, accounts, repos []entry) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+		setVersion(doc.Content[0], CurrentVersion)
+	}
+
+	target := doc.Content[0]
+	if profile != "" {
+		if profiles := mappingValue(target, "profiles"); profiles != nil {
+			target = mappingValue(profiles, profile)
+		}
+		if target == nil {
+			return nil, fmt.Errorf("profile %q is no longer in the file", profile)
+		}
+		if target.Kind != yaml.MappingNode {
+			// An empty profile, as in "work:" with nothing below it.
+			*target = yaml.Node{Kind: yaml.MappingNode}
+		}
+		target.Style = 0
+	}
+
+	accountsChanged, err := setEntries(target, "accounts", accounts)
+	if err != nil {
+		return nil, err
+	}
+	reposChanged, err := setEntries(target, "repos", repos)
+	if err != nil {
+		return nil, err
+	}
+	if !accountsChanged && !reposChanged {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	encoder.Close()
+	return buf.Bytes(), nil
+}
+
+// setEntries sets the list under key in a mapping node to entries, reusing
+// the node of each unchanged entry so it keeps its form and comments. The
+// key is removed if there are no entries. It reports whether the list
+// changed.
+func setEntries(mapping *yaml.Node, key string, entries []entry) (bool, error) {
+	list := mappingValue(mapping, key)
+	var items []*yaml.Node
+	if list != nil && list.Kind == yaml.SequenceNode {
+		items = list.Content
+	}
+
+	changed := len(entries) != len(items)
+	for i, e := range entries {
+		if e.index != i {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	if len(entries) == 0 {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+				break
+			}
+		}
+		return true, nil
+	}
+
+	content := make([]*yaml.Node, len(entries))
+	for i, e := range entries {
+		if e.index >= 0 {
+			content[i] = items[e.index]
+			continue
+		}
+		var node yaml.Node
+		if err := node.Encode(e.value); err != nil {
+			return false, err
+		}
+		content[i] = &node
+	}
+	if list == nil {
+		list = &yaml.Node{}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, list)
+	}
+	if list.Kind != yaml.SequenceNode || len(items) == 0 {
+		// A missing, null or empty list, as in "repos:" or "repos: []".
+		*list = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	list.Content = content
+	return true, nil
+}
+
+func (c *Config) findAccountByOrigin(o origin) *Account {
+	for i := range c.Accounts {
+		if c.Accounts[i].origin == o {
+			return &c.Accounts[i]
+		}
+	}
+	return nil
+}
+
+func (c *Config) findRepoByOrigin(o origin) *Repo {
+	for i := range c.Repos {
+		if c.Repos[i].origin == o {
+			return &c.Repos[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeLayers(t *testing.T) (system, user, project string) {
+	t.Helper()
+	system = writeTestConfig(t, `
+layout:
+  template: "{root}/{owner}/{name}"
+  root: /src
+accounts:
+  - username: acme
+    dir: /srv/acme
+`)
+	user = writeTestConfig(t, `
+accounts:
+  - username: ACME
+    dir: /code/acme
+    protocol: https
+  - username: jane
+    dir: /code/jane
+repos:
+  - name: dotfiles
+    owner: jane
+  - name: notes
+    owner: jane
+    tags: [personal]
+`)
+	project = filepath.Join(t.TempDir(), ProjectFile)
+	err := os.WriteFile(project, []byte(`
+repos:
+  - name: api
+    owner: acme
+  - name: tools
+    owner: infra
+    dir: services/tools
+  - name: notes
+    owner: jane
+    tags: [team]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return system, user, project
+}
+
+func TestLoadSources_Precedence(t *testing.T) {
+	system, user, project := writeLayers(t)
+
+	cfg, err := LoadSources([]Source{
+		{Scope: ScopeSystem, Path: system},
+		{Scope: ScopeUser, Path: user},
+		{Scope: ScopeProject, Path: project},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cfg.Accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %d", len(cfg.Accounts))
+	}
+	acme := cfg.Accounts[0]
+	if acme.Dir != "/code/acme" || acme.Protocol != "https" || acme.Origin() != user {
+		t.Errorf("expected the user file's acme to win, got %+v from %s", acme, acme.Origin())
+	}
+	if cfg.Layout.Root != "/src" {
+		t.Errorf("expected the system layout, got %+v", cfg.Layout)
+	}
+
+	api := cfg.FindRepoByDir("/code/acme/api")
+	if api == nil || api.Protocol != "https" || api.Origin() != project {
+		t.Errorf("expected api to inherit from the user file's account, got %+v", api)
+	}
+	tools := cfg.FindRepoByDir(filepath.Join(filepath.Dir(project), "services", "tools"))
+	if tools == nil {
+		t.Errorf("expected tools rooted at the project dir, got %+v", cfg.Repos)
+	}
+	notes := cfg.FindRepoByDir("/code/jane/notes")
+	if notes == nil || notes.Origin() != project || notes.Tags[0] != "team" {
+		t.Errorf("expected the project file's notes to win, got %+v", notes)
+	}
+	if len(cfg.Repos) != 4 {
+		t.Errorf("expected 4 repos, got %d", len(cfg.Repos))
+	}
+}
+
+func TestLoadSources_SkipsMissingFiles(t *testing.T) {
+	_, user, _ := writeLayers(t)
+	missing := filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg, err := LoadSources([]Source{{Scope: ScopeSystem, Path: missing}, {Scope: ScopeUser, Path: user}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sources := cfg.Sources(); len(sources) != 1 || sources[0].Path != user {
+		t.Errorf("expected only the user file, got %v", sources)
+	}
+
+	_, err = LoadSources([]Source{{Scope: ScopeUser, Path: missing}})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not-exist error with no files, got %v", err)
+	}
+}
+
+func TestLoadSources_InvalidFileNamed(t *testing.T) {
+	bad := writeTestConfig(t, `
+repos:
+  - name: api
+    dir: /code/api
+    protocol: ftp
+`)
+	_, err := LoadSources([]Source{{Scope: ScopeProject, Path: bad}})
+	if err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("expected an error naming %s, got %v", bad, err)
+	}
+}
+
+func TestSave_LayeredWritesOneFile(t *testing.T) {
+	system, user, project := writeLayers(t)
+	cfg, err := LoadSources([]Source{
+		{Scope: ScopeSystem, Path: system},
+		{Scope: ScopeUser, Path: user},
+		{Scope: ScopeProject, Path: project},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.FindRepoByDir("/code/jane/dotfiles").Name = "dots"
+	cfg.RemoveRepoByDir("/code/acme/api")
+	if err := cfg.AddRepo(Repo{Name: "blog", Owner: "jane", Dir: "/code/jane/blog", Protocol: "ssh"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, user); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := Load(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, repo := range saved.Repos {
+		names = append(names, repo.Name)
+	}
+	// notes is overridden by the project file but stays in the user file.
+	if strings.Join(names, ",") != "dots,notes,blog" {
+		t.Errorf("expected dots,notes,blog in the user file, got %v", names)
+	}
+	if len(saved.Accounts) != 2 || saved.Layout.Root != "" {
+		t.Errorf("expected only the user file's accounts and no layout, got %+v", saved)
+	}
+	if saved.Repos[1].Tags[0] != "personal" {
+		t.Errorf("expected the user file's own notes entry, got %+v", saved.Repos[1])
+	}
+}
+
+func TestSave_LeavesInheritedRepoFieldsOut(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: jane
+    dir: /code/jane
+    protocol: https
+repos:
+  - name: dotfiles
+    owner: jane
+`)
+	cfg, err := LoadSources([]Source{{Scope: ScopeUser, Path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AddAccount(Account{Username: "other", Dir: "/code/other", Protocol: "ssh"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := strings.Replace(string(data), "dir: /code/jane", "dir: /src/jane", 1)
+	moved = strings.Replace(moved, "protocol: https", "protocol: ssh", 1)
+	if err := os.WriteFile(path, []byte(moved), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := LoadSources([]Source{{Scope: ScopeUser, Path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := saved.Repos[0]
+	if repo.Dir != "/src/jane/dotfiles" || repo.Protocol != "ssh" {
+		t.Errorf("expected the repo to follow its account, got dir %q protocol %q", repo.Dir, repo.Protocol)
+	}
+}
+
+func TestSave_UnchangedLeavesFileAsWritten(t *testing.T) {
+	content := `# shared workspace
+accounts:
+  - username: boycook
+    dir: ~/code/boycook # personal
+repos:
+  - name: tool
+    owner: boycook
+  - name: web
+    owner: boycook
+    dir: frontend/web
+`
+	path := writeTestConfig(t, content)
+	cfg, err := LoadSources([]Source{{Scope: ScopeUser, Path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("expected the file to be unchanged, got:\n%s", data)
+	}
+}
+
+func TestSave_KeepsEntriesAsWritten(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: boycook
+    dir: ~/code/boycook
+repos:
+  - name: tool
+    owner: boycook
+  - name: web
+    owner: boycook
+    dir: frontend/web
+`)
+	cfg, err := LoadSources([]Source{{Scope: ScopeUser, Path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.AddAccount(Account{Username: "other", Dir: "/code/other", Protocol: "ssh"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Repos[1].Tags = []string{"frontend"}
+	if err := Save(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file Config
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Accounts[0].Dir != "~/code/boycook" || file.Accounts[0].Protocol != "" {
+		t.Errorf("expected the account as written, got %+v", file.Accounts[0])
+	}
+	if file.Repos[0].Dir != "" || file.Repos[0].Protocol != "" {
+		t.Errorf("expected tool to keep inheriting, got %+v", file.Repos[0])
+	}
+	if file.Repos[1].Dir != "frontend/web" || file.Repos[1].Protocol != "" || len(file.Repos[1].Tags) != 1 {
+		t.Errorf("expected web with its relative dir and new tag, got %+v", file.Repos[1])
+	}
+	if len(file.Accounts) != 2 || file.Accounts[1].Dir != "/code/other" {
+		t.Errorf("expected the new account to be added, got %+v", file.Accounts)
+	}
+}
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindProjectFile(nested); got != "" && strings.HasPrefix(got, root) {
+		t.Errorf("expected no project file yet, got %s", got)
+	}
+
+	project := filepath.Join(root, ProjectFile)
+	if err := os.WriteFile(project, []byte("repos: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindProjectFile(nested); got != project {
+		t.Errorf("expected %s, got %s", project, got)
+	}
+}
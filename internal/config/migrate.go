@@ -0,0 +1,192 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config schema version this build reads and writes.
+// Version 0 is the JSON array of accounts used by the original Node gitall;
+// YAML files without a version: field are version 1.
+const CurrentVersion = 1
+
+// migration upgrades a config document from one version to the next. It
+// may replace the root node, so it returns the node to carry on with.
+type migration struct {
+	about string
+	apply func(root *yaml.Node) (*yaml.Node, error)
+}
+
+// migrations[v] upgrades a version v config to version v+1.
+var migrations = []migration{
+	{"converted the legacy account list to accounts:", migrateLegacyAccounts},
+}
+
+// Migration is the result of migrating a config file.
+type Migration struct {
+	From  int      // version the file was written for
+	Steps []string // what each applied migration did
+	Data  []byte   // the migrated file; the input itself if nothing changed
+}
+
+// Changed reports whether migrating rewrote the file.
+func (m *Migration) Changed() bool {
+	return len(m.Steps) > 0
+}
+
+// Migrate upgrades a config file to CurrentVersion. Comments and key order
+// in YAML files are kept. A file that is already current is returned as is.
+func Migrate(data []byte) (*Migration, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return &Migration{From: CurrentVersion, Data: data}, nil
+	}
+
+	root := doc.Content[0]
+	from, stamped, err := detectVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	if from > CurrentVersion {
+		return nil, fmt.Errorf("config version %d is newer than this gitall supports (%d) — upgrade gitall", from, CurrentVersion)
+	}
+
+	result := &Migration{From: from, Data: data}
+	if stamped && from == CurrentVersion {
+		return result, nil
+	}
+
+	for version := from; version < CurrentVersion; version++ {
+		step := migrations[version]
+		if root, err = step.apply(root); err != nil {
+			return nil, fmt.Errorf("migrating config from version %d: %w", version, err)
+		}
+		result.Steps = append(result.Steps, step.about)
+	}
+	setVersion(root, CurrentVersion)
+	result.Steps = append(result.Steps, fmt.Sprintf("set version: %d", CurrentVersion))
+
+	doc.Content[0] = root
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encoding migrated config: %w", err)
+	}
+	encoder.Close()
+	result.Data = buf.Bytes()
+	return result, nil
+}
+
+// detectVersion returns the schema version of a config document, and
+// whether the document states it.
+func detectVersion(root *yaml.Node) (int, bool, error) {
+	switch root.Kind {
+	case yaml.SequenceNode:
+		return 0, false, nil
+	case yaml.MappingNode:
+		value := mappingValue(root, "version")
+		if value == nil {
+			return 1, false, nil
+		}
+		version, err := strconv.Atoi(value.Value)
+		if err != nil || version < 0 {
+			return 0, false, fmt.Errorf("invalid config version %q", value.Value)
+		}
+		return version, true, nil
+	}
+	return 0, false, fmt.Errorf("config file must be a mapping or a list of accounts")
+}
+
+// migrateLegacyAccounts turns the Node gitall's list of accounts into an
+// accounts: mapping. The svn protocol is mapped to https, since GitHub no
+// longer serves Subversion.
+func migrateLegacyAccounts(root *yaml.Node) (*yaml.Node, error) {
+	for i, account := range root.Content {
+		if account.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("account %d is not a mapping", i+1)
+		}
+		if protocol := mappingValue(account, "protocol"); protocol != nil && protocol.Value == "svn" {
+			protocol.Value = "https"
+		}
+	}
+
+	// JSON parses as flow style; clear it so the result is block YAML.
+	clearStyle(root)
+	return &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: "accounts"},
+			root,
+		},
+	}, nil
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setVersion sets version: at the top of a mapping.
+func setVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	if existing := mappingValue(root, "version"); existing != nil {
+		existing.Value = value
+		return
+	}
+	root.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "version"},
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: value},
+	}, root.Content...)
+}
+
+// LegacyPath returns where the Node gitall kept its config, next to the
+// YAML config at path.
+func LegacyPath(path string) string {
+	return filepath.Join(filepath.Dir(expandPath(path)), "config.json")
+}
+
+// BackupFile copies a file to path.bak, or path.bak.1, path.bak.2 and so on
+// if earlier backups exist, and returns the backup's path.
+func BackupFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	backup := path + ".bak"
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s.bak.%d", path, i)
+	}
+
+	if err := os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("writing backup: %w", err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacyJSON = `[
+  {
+    "username": "BoyCook",
+    "dir": "/code/boycook",
+    "protocol": "ssh",
+    "active": true
+  },
+  {
+    "username": "SomeOrg",
+    "dir": "/code/org",
+    "protocol": "svn",
+    "active": false
+  }
+]
+`
+
+func TestMigrate_LegacyJSONArray(t *testing.T) {
+	m, err := Migrate([]byte(legacyJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.From != 0 || !m.Changed() {
+		t.Errorf("expected a change from version 0, got %+v", m)
+	}
+	if !strings.HasPrefix(string(m.Data), "version: 1\naccounts:\n  - username: BoyCook\n") {
+		t.Errorf("expected block YAML with version first, got:\n%s", m.Data)
+	}
+
+	cfg, err := Parse(m.Data, "")
+	if err != nil {
+		t.Fatalf("migrated config does not parse: %v", err)
+	}
+	if len(cfg.Accounts) != 2 || cfg.Accounts[1].Protocol != "https" || cfg.Accounts[1].IsActive() {
+		t.Errorf("unexpected accounts %+v", cfg.Accounts)
+	}
+}
+
+func TestMigrate_StampsUnversionedYAMLKeepingComments(t *testing.T) {
+	m, err := Migrate([]byte("# my accounts\naccounts:\n  - username: jane # me\n    dir: /code\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "version: 1\n# my accounts\naccounts:\n  - username: jane # me\n    dir: /code\n"
+	if m.From != 1 || string(m.Data) != expected {
+		t.Errorf("expected version stamped and comments kept, got from %d:\n%s", m.From, m.Data)
+	}
+}
+
+func TestMigrate_CurrentVersionIsUnchanged(t *testing.T) {
+	data := []byte("version: 1\naccounts: [{username: jane, dir: /code}]\n")
+	m, err := Migrate(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Changed() || string(m.Data) != string(data) {
+		t.Errorf("expected the file untouched, got %+v", m)
+	}
+}
+
+func TestMigrate_RejectsNewerVersion(t *testing.T) {
+	_, err := Migrate([]byte("version: 99\n"))
+	if err == nil || !strings.Contains(err.Error(), "upgrade gitall") {
+		t.Errorf("expected a newer-version error, got %v", err)
+	}
+}
+
+func TestLoad_FallsBackToLegacyJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(legacyJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Accounts) != 2 || cfg.Version != CurrentVersion {
+		t.Errorf("expected the legacy accounts at the current version, got %+v", cfg)
+	}
+}
+
+func TestBackupFile_PicksFreeName(t *testing.T) {
+	path := writeTestConfig(t, "accounts: []\n")
+	first, err := BackupFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := BackupFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first != path+".bak" || second != path+".bak.1" {
+		t.Errorf("unexpected backups %q, %q", first, second)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	lines := Diff("a\nb\nc\n", "a\nx\nc\nd\n")
+
+	var got []string
+	for _, line := range lines {
+		got = append(got, string(line.Op)+line.Text)
+	}
+	expected := " a,-b,+x, c,+d"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("expected %q, got %q", expected, strings.Join(got, ","))
+	}
+}
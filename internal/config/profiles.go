@@ -0,0 +1,216 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/boycook/gitall/internal/layout"
+	"gopkg.in/yaml.v3"
+)
+
+// ScopeProfile is a profile file under ProfileDir, which takes the place of
+// the user file.
+const ScopeProfile = "profile"
+
+// DefaultProfile names the user file's own accounts and repos, outside any
+// profile.
+const DefaultProfile = "default"
+
+// Profile is a named workspace in the profiles: section of the user file,
+// with its own accounts, repos and tokens. Its settings sections replace
+// those of the file.
+type Profile struct {
+	Accounts []Account     `yaml:"accounts,omitempty"`
+	Repos    []Repo        `yaml:"repos,omitempty"`
+	Sync     Sync          `yaml:"sync,omitempty"`
+	Layout   layout.Layout `yaml:"layout,omitempty"`
+	Backup   Backup        `yaml:"backup,omitempty"`
+}
+
+// ProfileInfo describes a profile for `gitall profile list`.
+type ProfileInfo struct {
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+}
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	if strings.EqualFold(name, DefaultProfile) {
+		return fmt.Errorf("profile name %q is reserved for the entries outside profiles", name)
+	}
+	return nil
+}
+
+// ProfileDir returns the directory of per-profile config files.
+func ProfileDir() string {
+	return filepath.Join(filepath.Dir(DefaultPath()), "profiles")
+}
+
+// ProfilePath returns the config file of a profile under ProfileDir.
+func ProfilePath(name string) string {
+	return filepath.Join(ProfileDir(), name+".yaml")
+}
+
+// profileHeader is the part of a user file that selects profiles.
+type profileHeader struct {
+	Profile  string               `yaml:"profile"`
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+func readProfileHeader(path string) profileHeader {
+	var header profileHeader
+	if data, err := readConfigFile(expandPath(path)); err == nil {
+		// A legacy account list has no profiles and fails to decode here.
+		_ = yaml.Unmarshal(data, &header)
+	}
+	return header
+}
+
+// DefaultProfileName returns the profile the user file at path selects with
+// profile:, or "" if it selects none.
+func DefaultProfileName(path string) string {
+	return readProfileHeader(path).Profile
+}
+
+// FindProfile returns the source to load in place of user for a profile: its
+// file under ProfileDir, or else its entry in the profiles: section of user.
+// The default profile is user itself.
+func FindProfile(user Source, name string) (Source, error) {
+	if strings.EqualFold(name, DefaultProfile) {
+		return user, nil
+	}
+	if err := validateProfileName(name); err != nil {
+		return Source{}, err
+	}
+	if _, err := os.Stat(ProfilePath(name)); err == nil {
+		return Source{Scope: ScopeProfile, Path: ProfilePath(name)}, nil
+	}
+	if _, ok := readProfileHeader(user.Path).Profiles[name]; ok {
+		return Source{Scope: user.Scope, Path: user.Path, Profile: name}, nil
+	}
+	return Source{}, fmt.Errorf("unknown profile %q — see 'gitall profile list'", name)
+}
+
+// ListProfiles returns the profiles defined in the user file and under
+// ProfileDir, sorted by name. A profile file hides an entry of the same
+// name in the user file.
+func ListProfiles(user Source) ([]ProfileInfo, error) {
+	found := map[string]Source{}
+	for name := range readProfileHeader(user.Path).Profiles {
+		found[name] = Source{Scope: user.Scope, Path: user.Path, Profile: name}
+	}
+
+	entries, err := os.ReadDir(ProfileDir())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading profiles: %w", err)
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if !ok || entry.IsDir() || validateProfileName(name) != nil {
+			continue
+		}
+		found[name] = Source{Scope: ScopeProfile, Path: filepath.Join(ProfileDir(), entry.Name())}
+	}
+
+	profiles := make([]ProfileInfo, 0, len(found))
+	for name, source := range found {
+		profiles = append(profiles, ProfileInfo{Name: name, Source: source})
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// withProfile returns the config of one entry of the profiles: section:
+// its accounts and repos, and the file's settings overridden by its own.
+func (c *Config) withProfile(name string) (*Config, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	cfg := *c
+	cfg.Profiles = nil
+	cfg.Accounts = slices.Clone(profile.Accounts)
+	cfg.Repos = slices.Clone(profile.Repos)
+	if profile.Sync != (Sync{}) {
+		cfg.Sync = profile.Sync
+	}
+	if profile.Layout != (layout.Layout{}) {
+		cfg.Layout = profile.Layout
+	}
+	if profile.Backup != (Backup{}) {
+		cfg.Backup = profile.Backup
+	}
+	return &cfg, nil
+}
+
+// SetDefaultProfile sets the profile: key of the config file at path,
+// keeping the rest of the file as it is. The default profile clears it.
+func SetDefaultProfile(path, name string) error {
+	expanded := expandPath(path)
+	data, err := os.ReadFile(expanded)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = nil, nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	migration, err := Migrate(data)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(migration.Data, &doc); err != nil {
+		return fmt.Errorf("parsing config file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+		setVersion(doc.Content[0], CurrentVersion)
+	}
+	root := doc.Content[0]
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "profile" {
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+	if !strings.EqualFold(name, DefaultProfile) {
+		// Keep profile: next to version: at the top of the file.
+		at := 0
+		if len(root.Content) >= 2 && root.Content[0].Value == "version" {
+			at = 2
+		}
+		root.Content = slices.Insert(root.Content, at,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "profile"},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: name})
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	encoder.Close()
+
+	if err := os.MkdirAll(filepath.Dir(expanded), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(expanded, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const profilesConfig = `version: 1
+# personal repos
+repos:
+  - name: dotfiles
+    owner: jane
+    dir: /code/jane/dotfiles
+layout:
+  root: /src
+profiles:
+  # the day job
+  work:
+    accounts:
+      - username: acme
+        dir: /code/acme
+        token: ghp_work
+    layout:
+      root: /work
+  oss:
+    repos:
+      - name: cobra
+        owner: spf13
+        dir: /code/oss/cobra
+`
+
+func setupProfiles(t *testing.T) Source {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	user := Source{Scope: ScopeUser, Path: DefaultPath()}
+	if err := os.MkdirAll(ProfileDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(user.Path, []byte(profilesConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ProfilePath("oss"), []byte("repos:\n  - name: tea\n    owner: charm\n    dir: /code/oss/tea\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestFindProfile(t *testing.T) {
+	user := setupProfiles(t)
+
+	if source, err := FindProfile(user, "work"); err != nil || source.Path != user.Path || source.Profile != "work" {
+		t.Errorf("expected the inline work profile, got %+v (%v)", source, err)
+	}
+	if source, err := FindProfile(user, "oss"); err != nil || source.Path != ProfilePath("oss") || source.Profile != "" {
+		t.Errorf("expected the oss profile file to win over the inline one, got %+v (%v)", source, err)
+	}
+	if source, err := FindProfile(user, "default"); err != nil || source != user {
+		t.Errorf("expected the default profile to be the user file, got %+v (%v)", source, err)
+	}
+	if _, err := FindProfile(user, "missing"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+	if _, err := FindProfile(user, "../etc"); err == nil {
+		t.Error("expected an error for an invalid profile name")
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	user := setupProfiles(t)
+
+	profiles, err := ListProfiles(user)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "oss" || profiles[1].Name != "work" {
+		t.Fatalf("expected oss and work, got %+v", profiles)
+	}
+	if profiles[0].Source.Scope != ScopeProfile || profiles[1].Source.Profile != "work" {
+		t.Errorf("expected oss from its file and work inline, got %+v", profiles)
+	}
+}
+
+func TestLoadSources_Profile(t *testing.T) {
+	user := setupProfiles(t)
+
+	cfg, err := LoadSources([]Source{{Scope: ScopeUser, Path: user.Path, Profile: "work"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Accounts) != 1 || cfg.Accounts[0].Token != "ghp_work" || len(cfg.Repos) != 0 {
+		t.Errorf("expected only the work profile's entries, got %+v", cfg)
+	}
+	if cfg.Layout.Root != "/work" {
+		t.Errorf("expected the profile's layout, got %+v", cfg.Layout)
+	}
+}
+
+func TestSave_IntoProfile(t *testing.T) {
+	user := setupProfiles(t)
+	cfg, err := LoadSources([]Source{{Scope: ScopeUser, Path: user.Path, Profile: "work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.AddRepo(Repo{Name: "api", Owner: "acme", Dir: "/code/acme/api", Protocol: "ssh"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, user.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(user.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# personal repos", "# the day job", "name: dotfiles", "name: cobra"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected the file to keep %q, got:\n%s", want, data)
+		}
+	}
+
+	saved, err := LoadSources([]Source{{Scope: ScopeUser, Path: user.Path, Profile: "work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Repos) != 1 || saved.Repos[0].Name != "api" || len(saved.Accounts) != 1 {
+		t.Errorf("expected the new repo in the work profile, got %+v", saved)
+	}
+}
+
+func TestLoadSources_OnlyProfiles(t *testing.T) {
+	path := writeTestConfig(t, "profiles:\n  work:\n    accounts:\n      - username: acme\n        dir: /code/acme\n")
+	_, err := LoadSources([]Source{{Scope: ScopeUser, Path: path}})
+	if err == nil || !strings.Contains(err.Error(), "--profile") {
+		t.Errorf("expected a hint to choose a profile, got %v", err)
+	}
+}
+
+func TestSetDefaultProfile(t *testing.T) {
+	user := setupProfiles(t)
+
+	if err := SetDefaultProfile(user.Path, "work"); err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultProfileName(user.Path); got != "work" {
+		t.Errorf("expected work, got %q", got)
+	}
+	data, _ := os.ReadFile(user.Path)
+	if !strings.HasPrefix(string(data), "version: 1\nprofile: work\n") || !strings.Contains(string(data), "# the day job") {
+		t.Errorf("expected profile: after version: and comments kept, got:\n%s", data)
+	}
+
+	if err := SetDefaultProfile(user.Path, DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultProfileName(user.Path); got != "" {
+		t.Errorf("expected the default profile to clear profile:, got %q", got)
+	}
+
+	fresh := filepath.Join(t.TempDir(), "config.yaml")
+	if err := SetDefaultProfile(fresh, "oss"); err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultProfileName(fresh); got != "oss" {
+		t.Errorf("expected a new file selecting oss, got %q", got)
+	}
+}
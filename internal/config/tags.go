@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Selector picks accounts and repos by tag. It is parsed from terms such as
+// "backend,!legacy": an entry is selected when it has any of the plain tags
+// (or there are none) and none of the tags prefixed with "!".
+type Selector struct {
+	include []string
+	exclude []string
+}
+
+// ParseSelector reads selector terms. Each term may itself be a
+// comma-separated list, so "--tag a --tag !b" and "--select a,!b" agree.
+func ParseSelector(terms ...string) (Selector, error) {
+	var s Selector
+	for _, term := range terms {
+		for _, tag := range strings.Split(term, ",") {
+			tag = strings.TrimSpace(tag)
+			negated := strings.HasPrefix(tag, "!")
+			tag = strings.TrimPrefix(tag, "!")
+			if err := validateTag(tag); err != nil {
+				return Selector{}, fmt.Errorf("invalid tag selector %q: %w", term, err)
+			}
+			if negated {
+				s.exclude = append(s.exclude, strings.ToLower(tag))
+			} else {
+				s.include = append(s.include, strings.ToLower(tag))
+			}
+		}
+	}
+	return s, nil
+}
+
+// IsEmpty reports whether the selector selects everything.
+func (s Selector) IsEmpty() bool {
+	return len(s.include) == 0 && len(s.exclude) == 0
+}
+
+// Matches reports whether an entry with tags is selected. Tags compare
+// case-insensitively.
+func (s Selector) Matches(tags []string) bool {
+	has := func(tag string) bool {
+		return slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}
+	for _, tag := range s.exclude {
+		if has(tag) {
+			return false
+		}
+	}
+	return len(s.include) == 0 || slices.ContainsFunc(s.include, has)
+}
+
+func validateTag(tag string) error {
+	switch {
+	case tag == "":
+		return fmt.Errorf("empty tag")
+	case strings.ContainsAny(tag, ",! \t"):
+		return fmt.Errorf("tag %q must not contain commas, spaces or !", tag)
+	}
+	return nil
+}
+
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RepoTags returns a repo's tags together with those of its account.
+func (c *Config) RepoTags(repo Repo) []string {
+	tags := slices.Clone(repo.Tags)
+	if account := c.FindAccount(repo.Owner); account != nil {
+		tags = mergeTags(tags, account.Tags)
+	}
+	return tags
+}
+
+// CheckoutTags returns the tags of a checkout of account's: the account's
+// own, plus those of a repo entry for the same dir.
+func (c *Config) CheckoutTags(account Account, dir string) []string {
+	tags := slices.Clone(account.Tags)
+	if repo := c.FindRepoByDir(dir); repo != nil {
+		tags = mergeTags(tags, repo.Tags)
+	}
+	return tags
+}
+
+func mergeTags(tags, more []string) []string {
+	for _, tag := range more {
+		if !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// DirTags returns the tags of a checkout found by scanning a directory: those
+// of the repo entry for dir, or else of the account whose dir contains it.
+func (c *Config) DirTags(dir string) []string {
+	if repo := c.FindRepoByDir(dir); repo != nil {
+		return c.RepoTags(*repo)
+	}
+	dir = filepath.Clean(dir)
+	for _, account := range c.Accounts {
+		if account.Dir != "" && strings.HasPrefix(dir, filepath.Clean(account.Dir)+string(filepath.Separator)) {
+			return slices.Clone(account.Tags)
+		}
+	}
+	return nil
+}
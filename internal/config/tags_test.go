@@ -0,0 +1,86 @@
+package config
+
+import "testing"
+
+func TestSelector_Matches(t *testing.T) {
+	selector, err := ParseSelector("backend,!legacy", "Infra")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		tags     []string
+		expected bool
+	}{
+		{[]string{"backend"}, true},
+		{[]string{"INFRA", "payments"}, true},
+		{[]string{"backend", "legacy"}, false},
+		{[]string{"frontend"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := selector.Matches(tt.tags); got != tt.expected {
+			t.Errorf("Matches(%v): expected %v, got %v", tt.tags, tt.expected, got)
+		}
+	}
+}
+
+func TestSelector_OnlyExclusions(t *testing.T) {
+	selector, err := ParseSelector("!legacy")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !selector.Matches(nil) || selector.Matches([]string{"legacy"}) {
+		t.Error("expected untagged repos to match and legacy ones not to")
+	}
+	if empty, _ := ParseSelector(); !empty.IsEmpty() || !empty.Matches(nil) {
+		t.Error("expected an empty selector to match everything")
+	}
+}
+
+func TestParseSelector_RejectsEmptyTerms(t *testing.T) {
+	for _, expr := range []string{"backend,,infra", "!", "two words"} {
+		if _, err := ParseSelector(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
+
+func TestRepoTags_IncludeAccountTags(t *testing.T) {
+	path := writeTestConfig(t, `
+accounts:
+  - username: acme
+    dir: /code/acme
+    tags: [work]
+repos:
+  - name: api
+    owner: acme
+    tags: [backend, work]
+  - name: dotfiles
+    owner: jane
+    dir: /code/dotfiles
+    tags: [personal]
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tags := cfg.RepoTags(cfg.Repos[0]); len(tags) != 2 || tags[0] != "backend" || tags[1] != "work" {
+		t.Errorf("expected [backend work], got %v", tags)
+	}
+	if tags := cfg.RepoTags(cfg.Repos[1]); len(tags) != 1 || tags[0] != "personal" {
+		t.Errorf("expected [personal], got %v", tags)
+	}
+	if tags := cfg.CheckoutTags(cfg.Accounts[0], "/code/acme/api"); len(tags) != 2 {
+		t.Errorf("expected the account and repo entry tags, got %v", tags)
+	}
+}
+
+func TestValidate_InvalidTag(t *testing.T) {
+	cfg := &Config{Repos: []Repo{{Name: "api", Dir: "/code/api", Protocol: "ssh", Tags: []string{"!legacy"}}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for a tag starting with !, got nil")
+	}
+}
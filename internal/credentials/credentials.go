@@ -0,0 +1,160 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Source string
+
+const (
+	SourceConfig        Source = "config"
+	SourceGitHubToken   Source = "GITHUB_TOKEN"
+	SourceGHToken       Source = "GH_TOKEN"
+	SourceGHCLI         Source = "gh hosts.yml"
+	SourceGitCredential Source = "git credential"
+	SourceNone          Source = "none"
+)
+
+// Token holds a resolved credential. Its String and MarshalJSON methods mask
+// the value so that a token can never leak into logs or --json output by
+// accident; use Value to get the secret itself.
+type Token struct {
+	value  string
+	Source Source
+}
+
+// NewToken wraps a token found outside the resolver, such as by a provider
+// with its own environment variables.
+func NewToken(value string, source Source) Token {
+	if value == "" {
+		return Token{Source: SourceNone}
+	}
+	return Token{value: value, Source: source}
+}
+
+func (t Token) Value() string {
+	return t.value
+}
+
+func (t Token) IsSet() bool {
+	return t.value != ""
+}
+
+func (t Token) String() string {
+	return Mask(t.value)
+}
+
+func (t Token) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Mask(t.value) + `"`), nil
+}
+
+// Mask hides all but a recognisable prefix of a token, e.g. "ghp_****".
+func Mask(token string) string {
+	if token == "" {
+		return ""
+	}
+	if prefix, _, ok := strings.Cut(token, "_"); ok && len(prefix) <= 10 {
+		return prefix + "_****"
+	}
+	return "****"
+}
+
+type Resolver struct {
+	Getenv        func(string) string
+	GHConfigDir   string
+	GitCredential func(host string) string
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		Getenv:        os.Getenv,
+		GHConfigDir:   ghConfigDir(),
+		GitCredential: gitCredentialFill,
+	}
+}
+
+// Resolve finds a token for a host such as github.com, trying in order: the
+// account's configured token, GITHUB_TOKEN, GH_TOKEN, the gh CLI's
+// hosts.yml, and `git credential fill` for the host.
+func (r *Resolver) Resolve(configToken, host string) Token {
+	if configToken != "" {
+		return Token{value: configToken, Source: SourceConfig}
+	}
+	if token := r.Getenv("GITHUB_TOKEN"); token != "" {
+		return Token{value: token, Source: SourceGitHubToken}
+	}
+	if token := r.Getenv("GH_TOKEN"); token != "" {
+		return Token{value: token, Source: SourceGHToken}
+	}
+
+	if token := ghHostsToken(r.GHConfigDir, host); token != "" {
+		return Token{value: token, Source: SourceGHCLI}
+	}
+	if r.GitCredential != nil {
+		if token := r.GitCredential(host); token != "" {
+			return Token{value: token, Source: SourceGitCredential}
+		}
+	}
+
+	return Token{Source: SourceNone}
+}
+
+func ghConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gh")
+}
+
+type ghHost struct {
+	OAuthToken string `yaml:"oauth_token"`
+}
+
+func ghHostsToken(configDir, host string) string {
+	if configDir == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "hosts.yml"))
+	if err != nil {
+		return ""
+	}
+
+	var hosts map[string]ghHost
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return ""
+	}
+	return hosts[host].OAuthToken
+}
+
+func gitCredentialFill(host string) string {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return parseCredentialPassword(out)
+}
+
+func parseCredentialPassword(out []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if password, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+			return password
+		}
+	}
+	return ""
+}
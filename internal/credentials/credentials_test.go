@@ -0,0 +1,144 @@
+package credentials
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestResolver(env map[string]string) *Resolver {
+	return &Resolver{
+		Getenv:        func(key string) string { return env[key] },
+		GitCredential: func(host string) string { return "" },
+	}
+}
+
+func TestResolve_PrefersConfigToken(t *testing.T) {
+	r := newTestResolver(map[string]string{"GITHUB_TOKEN": "ghp_env"})
+
+	token := r.Resolve("ghp_config", "github.com")
+
+	expectedSource := SourceConfig
+	if token.Source != expectedSource {
+		t.Errorf("expected source %q, got %q", expectedSource, token.Source)
+	}
+	if token.Value() != "ghp_config" {
+		t.Errorf("expected config token value")
+	}
+}
+
+func TestResolve_FallsBackThroughEnvVars(t *testing.T) {
+	r := newTestResolver(map[string]string{"GH_TOKEN": "gho_env"})
+
+	token := r.Resolve("", "github.com")
+
+	expectedSource := SourceGHToken
+	if token.Source != expectedSource {
+		t.Errorf("expected source %q, got %q", expectedSource, token.Source)
+	}
+}
+
+func TestResolve_ReadsGHHostsFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(`
+github.com:
+    oauth_token: gho_fromgh
+    user: someone
+ghe.example.com:
+    oauth_token: gho_enterprise
+`), 0o600)
+
+	r := newTestResolver(nil)
+	r.GHConfigDir = dir
+
+	token := r.Resolve("", "ghe.example.com")
+
+	expectedSource := SourceGHCLI
+	if token.Source != expectedSource {
+		t.Errorf("expected source %q, got %q", expectedSource, token.Source)
+	}
+	if token.Value() != "gho_enterprise" {
+		t.Errorf("expected enterprise host token")
+	}
+}
+
+func TestResolve_UsesGitCredentialForHost(t *testing.T) {
+	var requestedHost string
+	r := newTestResolver(nil)
+	r.GitCredential = func(host string) string {
+		requestedHost = host
+		return "ghp_credential"
+	}
+
+	token := r.Resolve("", "github.com")
+
+	expectedHost := "github.com"
+	if requestedHost != expectedHost {
+		t.Errorf("expected host %q, got %q", expectedHost, requestedHost)
+	}
+
+	expectedSource := SourceGitCredential
+	if token.Source != expectedSource {
+		t.Errorf("expected source %q, got %q", expectedSource, token.Source)
+	}
+}
+
+func TestResolve_NoneFound(t *testing.T) {
+	token := newTestResolver(nil).Resolve("", "")
+
+	if token.IsSet() {
+		t.Error("expected no token")
+	}
+
+	expectedSource := SourceNone
+	if token.Source != expectedSource {
+		t.Errorf("expected source %q, got %q", expectedSource, token.Source)
+	}
+}
+
+func TestToken_NeverPrintsValue(t *testing.T) {
+	token := Token{value: "ghp_supersecretvalue", Source: SourceConfig}
+
+	if strings.Contains(token.String(), "supersecret") {
+		t.Errorf("String leaked token: %q", token.String())
+	}
+
+	data, err := json.Marshal(struct {
+		Token Token `json:"token"`
+	}{token})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "supersecret") {
+		t.Errorf("JSON leaked token: %s", data)
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ghp_abcdef", "ghp_****"},
+		{"github_pat_abc", "github_****"},
+		{"0123456789abcdef", "****"},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		if result := Mask(tc.input); result != tc.expected {
+			t.Errorf("Mask(%q): expected %q, got %q", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestParseCredentialPassword(t *testing.T) {
+	out := []byte("protocol=https\nhost=github.com\nusername=me\npassword=ghp_secret\n")
+
+	expected := "ghp_secret"
+	if result := parseCredentialPassword(out); result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+}
@@ -0,0 +1,299 @@
+// Package doctor checks the environment, credentials and checkouts for
+// problems that would make a gitall run fail, and suggests fixes.
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/git"
+)
+
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Result is the outcome of one check. Subject names what was checked, such
+// as a host, an account or a directory.
+type Result struct {
+	Check   string `json:"check"`
+	Subject string `json:"subject,omitempty"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Count returns how many results have a status.
+func Count(results []Result, status Status) int {
+	n := 0
+	for _, result := range results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// MinGitVersion is the oldest git that supports every clone option gitall
+// passes, including the tree:0 partial clone filter.
+var MinGitVersion = [3]int{2, 25, 0}
+
+var gitVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseGitVersion reads the version from `git version` output such as
+// "git version 2.43.0" or "git version 2.39.3 (Apple Git-146)".
+func ParseGitVersion(out string) ([3]int, bool) {
+	match := gitVersionPattern.FindStringSubmatch(out)
+	if match == nil {
+		return [3]int{}, false
+	}
+	var version [3]int
+	for i := range version {
+		version[i], _ = strconv.Atoi(match[i+1])
+	}
+	return version, true
+}
+
+func CheckGit() Result {
+	result := Result{Check: "git"}
+	out, err := exec.Command("git", "version").Output()
+	if err != nil {
+		result.Status = Fail
+		result.Message = "git is not installed or not on PATH"
+		result.Hint = "install git from https://git-scm.com"
+		return result
+	}
+
+	version, ok := ParseGitVersion(string(out))
+	if !ok {
+		result.Status = Warn
+		result.Message = fmt.Sprintf("could not read the git version from %q", strings.TrimSpace(string(out)))
+		return result
+	}
+
+	result.Message = fmt.Sprintf("git %d.%d.%d", version[0], version[1], version[2])
+	if compareVersions(version, MinGitVersion) < 0 {
+		result.Status = Warn
+		result.Message += fmt.Sprintf(" is older than %d.%d", MinGitVersion[0], MinGitVersion[1])
+		result.Hint = "upgrade git; partial clones (--partial) and some clone options need a newer version"
+		return result
+	}
+	result.Status = Pass
+	return result
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return 0
+}
+
+// CheckSSHAgent reports whether an SSH agent is running with keys loaded.
+// Keys on disk without an agent still work, so problems are warnings.
+func CheckSSHAgent() Result {
+	result := Result{Check: "ssh-agent"}
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		result.Status = Warn
+		result.Message = "no SSH agent (SSH_AUTH_SOCK is not set)"
+		result.Hint = "start one with 'eval $(ssh-agent)' and add a key with ssh-add, unless your keys have no passphrase"
+		return result
+	}
+
+	out, err := exec.Command("ssh-add", "-l").CombinedOutput()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		keys := len(strings.Split(strings.TrimSpace(string(out)), "\n"))
+		result.Status = Pass
+		result.Message = fmt.Sprintf("%d key(s) loaded", keys)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		result.Status = Warn
+		result.Message = "the SSH agent has no keys"
+		result.Hint = "add your key with ssh-add, e.g. 'ssh-add ~/.ssh/id_ed25519'"
+	default:
+		result.Status = Warn
+		result.Message = "cannot talk to the SSH agent: " + firstLine(string(out), err)
+		result.Hint = "check SSH_AUTH_SOCK points at a running agent"
+	}
+	return result
+}
+
+// CheckSSHHost tries an SSH login to host as git, the way clones over ssh
+// authenticate.
+func CheckSSHHost(host string) Result {
+	cmd := exec.Command("ssh", "-T",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		"-o", "StrictHostKeyChecking=accept-new",
+		"git@"+host)
+	out, err := cmd.CombinedOutput()
+
+	status, message, hint := ClassifySSH(string(out), err)
+	return Result{Check: "ssh", Subject: host, Status: status, Message: message, Hint: hint}
+}
+
+// ClassifySSH reads the outcome of `ssh -T git@host`. Forges greet a
+// recognised key and then close the session, often with a non-zero exit.
+func ClassifySSH(out string, err error) (Status, string, string) {
+	lower := strings.ToLower(out)
+	switch {
+	case strings.Contains(lower, "successfully authenticated"),
+		strings.Contains(lower, "welcome to"),
+		strings.Contains(lower, "authenticated via"),
+		strings.Contains(lower, "logged in as"):
+		return Pass, "key accepted", ""
+	case strings.Contains(lower, "permission denied"):
+		return Fail, "no key accepted", "add your public key to the forge and load it with ssh-add, or use protocol: https"
+	case strings.Contains(lower, "host key verification failed"):
+		return Fail, "host key verification failed", "check ~/.ssh/known_hosts for a stale entry for this host"
+	case strings.Contains(lower, "could not resolve hostname"),
+		strings.Contains(lower, "timed out"),
+		strings.Contains(lower, "connection refused"):
+		return Fail, "host unreachable: " + firstLine(out, err), "check the host name and your network or VPN"
+	case err == nil:
+		return Pass, "connected", ""
+	}
+	return Warn, "unexpected response: " + firstLine(out, err), "run 'ssh -T git@<host>' to see the full output"
+}
+
+func firstLine(out string, err error) string {
+	if line, _, _ := strings.Cut(strings.TrimSpace(out), "\n"); line != "" {
+		return line
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// CheckDir reports whether a configured directory exists. Missing account
+// dirs are only a warning, since clone creates them.
+func CheckDir(check, subject, dir, hint string) Result {
+	result := Result{Check: check, Subject: subject}
+	info, err := os.Stat(dir)
+	switch {
+	case err == nil && info.IsDir():
+		result.Status = Pass
+		result.Message = dir
+	case err == nil:
+		result.Status = Fail
+		result.Message = dir + " is a file, not a directory"
+		result.Hint = "move the file or point the config at another directory"
+	default:
+		result.Status = Warn
+		result.Message = dir + " does not exist"
+		result.Hint = hint
+	}
+	return result
+}
+
+// CheckOrigin compares a configured repo with the remote its checkout's
+// origin points at. ok is false when the remote could not be parsed.
+func CheckOrigin(repo config.Repo, remote git.Remote, ok bool, protocol string) Result {
+	result := Result{Check: "origin", Subject: repo.Dir}
+	if !ok {
+		result.Status = Fail
+		result.Message = "origin is missing or not a recognised repo URL"
+		result.Hint = "set it with 'git remote set-url origin <url>' or remove the repo with 'gitall config remove'"
+		return result
+	}
+
+	var mismatches []string
+	if !strings.EqualFold(remote.Owner, repo.Owner) {
+		mismatches = append(mismatches, fmt.Sprintf("owner %s, config says %s", remote.Owner, repo.Owner))
+	}
+	if !strings.EqualFold(remote.Name, repo.Name) {
+		mismatches = append(mismatches, fmt.Sprintf("name %s, config says %s", remote.Name, repo.Name))
+	}
+	if protocol != "" && repo.Protocol != "" && protocol != repo.Protocol {
+		mismatches = append(mismatches, fmt.Sprintf("protocol %s, config says %s", protocol, repo.Protocol))
+	}
+
+	if len(mismatches) == 0 {
+		result.Status = Pass
+		result.Message = remote.FullName()
+		return result
+	}
+	result.Status = Warn
+	result.Message = "origin has " + strings.Join(mismatches, "; ")
+	result.Hint = "run 'gitall relocate' for renamed or moved repos, or fix the entry in the config"
+	return result
+}
+
+// Dir is a directory the config refers to, for CheckOverlaps.
+type Dir struct {
+	Label   string // e.g. "account acme" or "repo acme/api"
+	Path    string
+	Account bool // an account dir, which is expected to contain repos
+}
+
+// CheckOverlaps finds dirs used twice, and repo dirs nested inside other
+// repos or account dirs nested inside other account dirs. Repos inside
+// account dirs are the normal layout.
+func CheckOverlaps(dirs []Dir) []Result {
+	sorted := make([]Dir, len(dirs))
+	copy(sorted, dirs)
+	for i := range sorted {
+		sorted[i].Path = filepath.Clean(sorted[i].Path)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	var results []Result
+	for i, outer := range sorted {
+		for _, inner := range sorted[i+1:] {
+			switch {
+			case inner.Path == outer.Path:
+				results = append(results, Result{
+					Check:   "dirs",
+					Subject: inner.Path,
+					Status:  Fail,
+					Message: fmt.Sprintf("used by both %s and %s", outer.Label, inner.Label),
+					Hint:    "give each entry its own dir, or remove the duplicate with 'gitall config remove'",
+				})
+			case strings.HasPrefix(inner.Path, outer.Path+string(filepath.Separator)) && outer.Account == inner.Account:
+				results = append(results, Result{
+					Check:   "dirs",
+					Subject: inner.Path,
+					Status:  Warn,
+					Message: fmt.Sprintf("%s is inside %s (%s)", inner.Label, outer.Label, outer.Path),
+					Hint:    "nested checkouts are picked up twice; move one of them elsewhere",
+				})
+			}
+		}
+	}
+	return results
+}
+
+// CheckIndexLock looks for an index.lock left behind by a git process that
+// crashed or was killed, which makes every later git command in the
+// checkout fail. It returns false when there is none.
+func CheckIndexLock(repoPath string, now time.Time) (Result, bool) {
+	lock := filepath.Join(repoPath, ".git", "index.lock")
+	info, err := os.Stat(lock)
+	if err != nil {
+		return Result{}, false
+	}
+	return Result{
+		Check:   "lock",
+		Subject: repoPath,
+		Status:  Fail,
+		Message: fmt.Sprintf("index.lock is %s old", now.Sub(info.ModTime()).Round(time.Second)),
+		Hint:    fmt.Sprintf("if no git command is running there, delete it: rm %s", lock),
+	}, true
+}
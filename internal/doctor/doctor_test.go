@@ -0,0 +1,110 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boycook/gitall/internal/config"
+	"github.com/boycook/gitall/internal/git"
+)
+
+func TestParseGitVersion(t *testing.T) {
+	tests := map[string][3]int{
+		"git version 2.43.0\n":               {2, 43, 0},
+		"git version 2.39.3 (Apple Git-146)": {2, 39, 3},
+		"git version 2.45.1.windows.1":       {2, 45, 1},
+		"git version 2.20":                   {2, 20, 0},
+	}
+	for input, expected := range tests {
+		got, ok := ParseGitVersion(input)
+		if !ok || got != expected {
+			t.Errorf("ParseGitVersion(%q): expected %v, got %v (%v)", input, expected, got, ok)
+		}
+	}
+
+	if _, ok := ParseGitVersion("not git"); ok {
+		t.Error("expected no version from unrelated output")
+	}
+}
+
+func TestClassifySSH(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+	tests := []struct {
+		out      string
+		err      error
+		expected Status
+	}{
+		{"Hi jane! You've successfully authenticated, but GitHub does not provide shell access.", exitErr, Pass},
+		{"Welcome to GitLab, @jane!", nil, Pass},
+		{"authenticated via ssh key.\n\nYou can use git to connect to Bitbucket.", nil, Pass},
+		{"git@github.com: Permission denied (publickey).", exitErr, Fail},
+		{"ssh: Could not resolve hostname git.example.com: Name or service not known", exitErr, Fail},
+		{"Host key verification failed.", exitErr, Fail},
+		{"something else", exitErr, Warn},
+	}
+	for _, tt := range tests {
+		if status, _, _ := ClassifySSH(tt.out, tt.err); status != tt.expected {
+			t.Errorf("ClassifySSH(%q): expected %s, got %s", tt.out, tt.expected, status)
+		}
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	repo := config.Repo{Name: "api", Owner: "acme", Dir: "/code/api", Protocol: "ssh"}
+
+	if result := CheckOrigin(repo, git.Remote{Owner: "Acme", Name: "api"}, true, "ssh"); result.Status != Pass {
+		t.Errorf("expected a matching origin to pass, got %+v", result)
+	}
+
+	result := CheckOrigin(repo, git.Remote{Owner: "other", Name: "api-v2"}, true, "https")
+	if result.Status != Warn || !strings.Contains(result.Message, "owner other") ||
+		!strings.Contains(result.Message, "name api-v2") || !strings.Contains(result.Message, "protocol https") {
+		t.Errorf("expected owner, name and protocol mismatches, got %+v", result)
+	}
+
+	if result := CheckOrigin(repo, git.Remote{}, false, ""); result.Status != Fail {
+		t.Errorf("expected a missing origin to fail, got %+v", result)
+	}
+}
+
+func TestCheckOverlaps(t *testing.T) {
+	results := CheckOverlaps([]Dir{
+		{Label: "account acme", Path: "/code/acme", Account: true},
+		{Label: "account acme-ops", Path: "/code/acme/ops", Account: true},
+		{Label: "repo acme/api", Path: "/code/acme/api"},
+		{Label: "repo jane/api", Path: "/code/acme/api/"},
+		{Label: "repo acme/docs", Path: "/code/acme/api/docs"},
+	})
+
+	var got []string
+	for _, result := range results {
+		got = append(got, string(result.Status)+" "+result.Subject)
+	}
+	expected := "warn /code/acme/ops,fail /code/acme/api,warn /code/acme/api/docs,warn /code/acme/api/docs"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("expected %q, got %q", expected, strings.Join(got, ","))
+	}
+}
+
+func TestCheckIndexLock(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := CheckIndexLock(dir, time.Now()); found {
+		t.Fatal("expected no lock in a fresh checkout")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".git", "index.lock"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, found := CheckIndexLock(dir, time.Now())
+	if !found || result.Status != Fail || !strings.Contains(result.Hint, "index.lock") {
+		t.Errorf("expected a failing lock result, got %+v", result)
+	}
+}
@@ -1,12 +1,14 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ResultStatus int
@@ -40,7 +42,45 @@ type RepoResult struct {
 	Message string       `json:"message"`
 }
 
+// CloneOptions are passed through to git clone. The zero value is a full
+// clone of every branch.
+type CloneOptions struct {
+	Depth             int    // --depth, 0 for full history
+	Filter            string // --filter for partial clones, e.g. blob:none or tree:0
+	SingleBranch      bool
+	Branch            string
+	RecurseSubmodules bool
+	Origin            string // remote name, defaults to origin
+}
+
+func (o CloneOptions) args() []string {
+	var args []string
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	if o.Filter != "" {
+		args = append(args, "--filter="+o.Filter)
+	}
+	if o.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if o.Branch != "" {
+		args = append(args, "--branch", o.Branch)
+	}
+	if o.RecurseSubmodules {
+		args = append(args, "--recurse-submodules")
+	}
+	if o.Origin != "" {
+		args = append(args, "--origin", o.Origin)
+	}
+	return args
+}
+
 func Clone(cloneURL, targetDir string) RepoResult {
+	return CloneWithOptions(cloneURL, targetDir, CloneOptions{})
+}
+
+func CloneWithOptions(cloneURL, targetDir string, opts CloneOptions) RepoResult {
 	name := repoNameFromDir(targetDir)
 
 	if isDir(targetDir) {
@@ -52,7 +92,9 @@ func Clone(cloneURL, targetDir string) RepoResult {
 		}
 	}
 
-	cmd := exec.Command("git", "clone", cloneURL, targetDir)
+	args := append([]string{"clone"}, opts.args()...)
+	args = append(args, "--", cloneURL, targetDir)
+	cmd := exec.Command("git", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return RepoResult{
@@ -130,6 +172,121 @@ func Status(repoPath string) RepoStatus {
 	return status
 }
 
+// UnpushedWork describes the work in a checkout that exists nowhere else:
+// uncommitted changes, untracked files, stashes, commits on any ref that no
+// remote-tracking branch contains, and tags the remote does not have. It
+// returns "" when the checkout can be deleted without losing anything but
+// its ignored files, which IgnoredFiles lists.
+func UnpushedWork(repoPath string) (string, error) {
+	if _, err := runGit(repoPath, "rev-parse", "--git-dir"); err != nil {
+		return "", fmt.Errorf("not a git repo: %s", repoPath)
+	}
+
+	var work []string
+	staged, unstaged, untracked := parsePortcelain(repoPath)
+	if staged > 0 || unstaged > 0 {
+		work = append(work, "uncommitted changes")
+	}
+	if untracked > 0 {
+		work = append(work, fmt.Sprintf("%d untracked files", untracked))
+	}
+	if out, _ := runGitRaw(repoPath, "stash", "list"); out != "" {
+		work = append(work, fmt.Sprintf("%d stashes", len(strings.Split(out, "\n"))))
+	}
+
+	// Every ref counts, so commits on a detached HEAD or only reachable
+	// from a tag are found too. The stash is reported above.
+	out, err := runGit(repoPath, "rev-list", "--count", "--exclude=refs/stash", "--all", "--not", "--remotes")
+	if err != nil {
+		return "", err
+	}
+	if count, _ := strconv.Atoi(out); count > 0 {
+		work = append(work, fmt.Sprintf("%d unpushed commits", count))
+	}
+
+	if tags := unpushedTags(repoPath); tags != "" {
+		work = append(work, tags)
+	}
+
+	return strings.Join(work, ", "), nil
+}
+
+// IgnoredFiles lists the ignored files in a checkout, such as a local .env,
+// which exist nowhere else. Ignored directories, such as node_modules/ or
+// build output, are left out, as they can be rebuilt.
+func IgnoredFiles(repoPath string) []string {
+	out, err := runGitRaw(repoPath, "status", "--porcelain", "--ignored")
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if path, ok := strings.CutPrefix(line, "!! "); ok && !strings.HasSuffix(path, "/") {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// remoteTimeout bounds the ls-remote that checks a checkout's tags, so an
+// unreachable remote cannot hang the command.
+var remoteTimeout = 15 * time.Second
+
+// unpushedTags describes the local tags that the primary remote lacks or
+// has at another commit. Tags that cannot be checked count as unpushed.
+func unpushedTags(dir string) string {
+	local, _ := runGit(dir, "for-each-ref", "refs/tags", "--format=%(objectname) %(refname)")
+	if local == "" {
+		return ""
+	}
+	tags := strings.Split(local, "\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", "--refs", primaryRemote(dir))
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	// ssh may outlive a killed git and hold its output open.
+	cmd.WaitDelay = time.Second
+	raw, err := cmd.Output()
+	out := strings.TrimSpace(string(raw))
+	if err != nil {
+		return fmt.Sprintf("%d tags not checked against the remote", len(tags))
+	}
+	remote := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		remote[strings.Replace(line, "\t", " ", 1)] = true
+	}
+
+	count := 0
+	for _, tag := range tags {
+		if !remote[tag] {
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d unpushed tags", count)
+}
+
+// RemoveCheckout deletes a checkout unless it holds unpushed work, which it
+// returns instead. With force it deletes the checkout anyway, and still
+// returns the work that was discarded. Ignored files do not keep a checkout.
+func RemoveCheckout(repoPath string, force bool) (work string, removed bool, err error) {
+	work, err = UnpushedWork(repoPath)
+	if err != nil {
+		return "", false, err
+	}
+	if work != "" && !force {
+		return work, false, nil
+	}
+	if err := os.RemoveAll(repoPath); err != nil {
+		return work, false, err
+	}
+	return work, true, nil
+}
+
 func DiscoverRepos(dir string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -157,11 +314,35 @@ func currentBranch(dir string) (string, error) {
 	return out, nil
 }
 
+// remoteURL returns the URL of the checkout's primary remote.
 func remoteURL(dir string) string {
-	out, _ := runGit(dir, "config", "--get", "remote.origin.url")
+	if out, err := runGit(dir, "config", "--get", "remote.origin.url"); err == nil {
+		return out
+	}
+	remote := primaryRemote(dir)
+	if remote == "origin" {
+		return ""
+	}
+	out, _ := runGit(dir, "config", "--get", "remote."+remote+".url")
 	return out
 }
 
+// primaryRemote returns the remote gitall reads and updates: origin, or the
+// first remote of a checkout cloned with another remote name.
+func primaryRemote(dir string) string {
+	out, err := runGit(dir, "remote")
+	if err != nil || out == "" {
+		return "origin"
+	}
+	remotes := strings.Fields(out)
+	for _, remote := range remotes {
+		if remote == "origin" {
+			return remote
+		}
+	}
+	return remotes[0]
+}
+
 func upstream(dir string) string {
 	out, err := runGit(dir, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
 	if err != nil {
@@ -322,35 +503,120 @@ func Fetch(repoPath string) RepoResult {
 	}
 }
 
+// Mirror keeps a bare mirror of a remote at targetDir: the first run makes a
+// --mirror clone, later runs fetch every ref and prune deleted ones.
+func Mirror(cloneURL, targetDir string) RepoResult {
+	result := RepoResult{Name: repoNameFromDir(targetDir), Path: targetDir}
+
+	if isDir(targetDir) {
+		out, err := runGit(targetDir, "remote", "update", "--prune")
+		if err != nil {
+			result.Status = Failed
+			result.Message = out
+			return result
+		}
+		result.Status = Success
+		result.Message = "updated"
+		return result
+	}
+
+	cmd := exec.Command("git", "clone", "--mirror", "--", cloneURL, targetDir)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		result.Status = Failed
+		result.Message = strings.TrimSpace(string(output))
+		return result
+	}
+	result.Status = Success
+	result.Message = "mirrored"
+	return result
+}
+
 func HasRemote(repoPath string) bool {
 	out, err := runGit(repoPath, "remote")
 	return err == nil && out != ""
 }
 
+type Remote struct {
+	Host  string
+	Owner string // may contain slashes for nested groups, e.g. "group/sub"
+	Name  string
+}
+
+func (r Remote) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
 func RemoteOwner(repoPath string) string {
-	url := remoteURL(repoPath)
-	if url == "" {
-		return ""
-	}
-	if strings.Contains(url, ":") && strings.Contains(url, "git@") {
-		parts := strings.SplitN(url, ":", 2)
-		if len(parts) == 2 {
-			pathParts := strings.Split(parts[1], "/")
-			if len(pathParts) >= 1 {
-				return pathParts[0]
-			}
+	remote, _ := ParseRemoteURL(remoteURL(repoPath))
+	return remote.Owner
+}
+
+func RemoteName(repoPath string) string {
+	remote, _ := ParseRemoteURL(remoteURL(repoPath))
+	return remote.Name
+}
+
+func RemoteHost(repoPath string) string {
+	remote, _ := ParseRemoteURL(remoteURL(repoPath))
+	return remote.Host
+}
+
+func RemoteURL(repoPath string) string {
+	return remoteURL(repoPath)
+}
+
+func SetRemoteURL(repoPath, url string) error {
+	_, err := runGit(repoPath, "remote", "set-url", primaryRemote(repoPath), url)
+	return err
+}
+
+// ParseRemoteURL splits a remote URL into host, owner and repo name. It
+// understands scp-like SSH (git@host:owner/name.git), ssh:// and http(s)://
+// URLs on any host, so GitHub Enterprise remotes parse the same way as
+// github.com ones. Local paths and file:// URLs are not recognised.
+func ParseRemoteURL(url string) (Remote, bool) {
+	var host, repoPath string
+
+	switch {
+	case url == "":
+		return Remote{}, false
+	case strings.Contains(url, "://"):
+		scheme, rest, _ := strings.Cut(url, "://")
+		if scheme == "file" {
+			return Remote{}, false
 		}
-	}
-	if strings.Contains(url, "github.com/") {
-		parts := strings.SplitAfter(url, "github.com/")
-		if len(parts) == 2 {
-			pathParts := strings.Split(parts[1], "/")
-			if len(pathParts) >= 1 {
-				return pathParts[0]
-			}
+		hostPart, pathPart, ok := strings.Cut(rest, "/")
+		if !ok {
+			return Remote{}, false
 		}
+		if _, after, found := strings.Cut(hostPart, "@"); found {
+			hostPart = after
+		}
+		host = hostPart
+		if scheme == "ssh" || scheme == "git+ssh" {
+			host, _, _ = strings.Cut(hostPart, ":")
+		}
+		repoPath = pathPart
+	case strings.Contains(url, "@") && strings.Contains(url, ":"):
+		userHost, pathPart, _ := strings.Cut(url, ":")
+		_, host, _ = strings.Cut(userHost, "@")
+		repoPath = pathPart
+	default:
+		return Remote{}, false
 	}
-	return ""
+
+	repoPath = strings.Trim(strings.TrimSuffix(strings.TrimSuffix(repoPath, "/"), ".git"), "/")
+	idx := strings.LastIndex(repoPath, "/")
+	if host == "" || idx <= 0 {
+		return Remote{}, false
+	}
+
+	return Remote{
+		Host:  strings.ToLower(host),
+		Owner: repoPath[:idx],
+		Name:  repoPath[idx+1:],
+	}, true
 }
 
 func RemoteProtocol(repoPath string) string {
@@ -4,7 +4,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func initTestRepo(t *testing.T) string {
@@ -166,6 +168,93 @@ func TestClone_FailsWithBadURL(t *testing.T) {
 	}
 }
 
+func TestCloneOptions_Args(t *testing.T) {
+	opts := CloneOptions{
+		Depth:             1,
+		Filter:            "blob:none",
+		SingleBranch:      true,
+		Branch:            "main",
+		RecurseSubmodules: true,
+		Origin:            "upstream",
+	}
+
+	got := strings.Join(opts.args(), " ")
+	expected := "--depth 1 --filter=blob:none --single-branch --branch main --recurse-submodules --origin upstream"
+	if got != expected {
+		t.Errorf("expected args %q, got %q", expected, got)
+	}
+
+	if args := (CloneOptions{}).args(); len(args) != 0 {
+		t.Errorf("expected no args for zero options, got %v", args)
+	}
+}
+
+func TestCloneWithOptions_ShallowBranchAndOrigin(t *testing.T) {
+	source := initTestRepo(t)
+	commitFile(t, source, "README.md", "one")
+	commitFile(t, source, "README.md", "two")
+	if _, err := runGit(source, "branch", "feature"); err != nil {
+		t.Fatalf("creating branch: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "clone")
+	result := CloneWithOptions("file://"+source, target, CloneOptions{
+		Depth:        1,
+		SingleBranch: true,
+		Branch:       "feature",
+		Origin:       "upstream",
+	})
+	if result.Status != Success {
+		t.Fatalf("expected success, got %v: %s", result.Status, result.Message)
+	}
+
+	if branch, _ := runGit(target, "rev-parse", "--abbrev-ref", "HEAD"); branch != "feature" {
+		t.Errorf("expected branch feature, got %q", branch)
+	}
+	if remotes, _ := runGit(target, "remote"); remotes != "upstream" {
+		t.Errorf("expected remote upstream, got %q", remotes)
+	}
+	if count, _ := runGit(target, "rev-list", "--count", "HEAD"); count != "1" {
+		t.Errorf("expected 1 commit in shallow clone, got %q", count)
+	}
+}
+
+func TestMirror_ClonesThenUpdates(t *testing.T) {
+	source := initTestRepo(t)
+	commitFile(t, source, "README.md", "one")
+	if _, err := runGit(source, "branch", "old"); err != nil {
+		t.Fatalf("creating branch: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "repo.git")
+	first := Mirror(source, target)
+	if first.Status != Success || first.Message != "mirrored" {
+		t.Fatalf("expected mirrored, got %v: %s", first.Status, first.Message)
+	}
+	if bare, _ := runGit(target, "rev-parse", "--is-bare-repository"); bare != "true" {
+		t.Errorf("expected a bare repository, got %q", bare)
+	}
+
+	commitFile(t, source, "README.md", "two")
+	if _, err := runGit(source, "branch", "-D", "old"); err != nil {
+		t.Fatalf("deleting branch: %v", err)
+	}
+
+	second := Mirror(source, target)
+	if second.Status != Success || second.Message != "updated" {
+		t.Fatalf("expected updated, got %v: %s", second.Status, second.Message)
+	}
+
+	sourceHead, _ := runGit(source, "rev-parse", "HEAD")
+	mirrorHead, _ := runGit(target, "rev-parse", "HEAD")
+	if sourceHead != mirrorHead {
+		t.Errorf("expected mirror HEAD %s, got %s", sourceHead, mirrorHead)
+	}
+	if _, err := runGit(target, "rev-parse", "--verify", "refs/heads/old"); err == nil {
+		t.Error("expected deleted branch to be pruned from the mirror")
+	}
+}
+
 func TestDiscoverRepos_FindsGitRepos(t *testing.T) {
 	dir := t.TempDir()
 
@@ -523,3 +612,196 @@ func setRemote(t *testing.T, dir, url string) {
 		t.Fatalf("adding remote: %s %v", out, err)
 	}
 }
+
+func TestRemoteName_StripsGitSuffix(t *testing.T) {
+	dir := initTestRepo(t)
+	commitFile(t, dir, "README.md", "hello")
+	setRemote(t, dir, "git@github.com:BoyCook/GitAll.git")
+
+	expectedName := "GitAll"
+	if name := RemoteName(dir); name != expectedName {
+		t.Errorf("expected name %q, got %q", expectedName, name)
+	}
+}
+
+func TestSetRemoteURL_UpdatesOrigin(t *testing.T) {
+	dir := initTestRepo(t)
+	commitFile(t, dir, "README.md", "hello")
+	setRemote(t, dir, "git@github.com:OldOwner/old-name.git")
+
+	if err := SetRemoteURL(dir, "git@github.com:NewOwner/new-name.git"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedOwner := "NewOwner"
+	if owner := RemoteOwner(dir); owner != expectedOwner {
+		t.Errorf("expected owner %q, got %q", expectedOwner, owner)
+	}
+
+	expectedName := "new-name"
+	if name := RemoteName(dir); name != expectedName {
+		t.Errorf("expected name %q, got %q", expectedName, name)
+	}
+}
+
+func TestRemoteURL_FallsBackToFirstRemote(t *testing.T) {
+	dir := initTestRepo(t)
+	cmd := exec.Command("git", "remote", "add", "upstream", "git@github.com:Owner/tool.git")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("adding remote: %s %v", out, err)
+	}
+
+	if owner := RemoteOwner(dir); owner != "Owner" {
+		t.Errorf("expected the upstream remote's owner, got %q", owner)
+	}
+	if err := SetRemoteURL(dir, "git@github.com:NewOwner/tool.git"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owner := RemoteOwner(dir); owner != "NewOwner" {
+		t.Errorf("expected the upstream remote to be updated, got %q", owner)
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected Remote
+		ok       bool
+	}{
+		{"git@github.com:BoyCook/GitAll.git", Remote{"github.com", "BoyCook", "GitAll"}, true},
+		{"https://github.com/BoyCook/GitAll.git", Remote{"github.com", "BoyCook", "GitAll"}, true},
+		{"https://github.com/BoyCook/GitAll", Remote{"github.com", "BoyCook", "GitAll"}, true},
+		{"git@ghe.example.com:team/service.git", Remote{"ghe.example.com", "team", "service"}, true},
+		{"https://user@GHE.example.com/team/service.git", Remote{"ghe.example.com", "team", "service"}, true},
+		{"ssh://git@ghe.example.com:2222/team/service.git", Remote{"ghe.example.com", "team", "service"}, true},
+		{"git@gitlab.example.com:group/sub/project.git", Remote{"gitlab.example.com", "group/sub", "project"}, true},
+		{"https://gitlab.example.com/group/sub/project.git", Remote{"gitlab.example.com", "group/sub", "project"}, true},
+		{"/local/path/repo", Remote{}, false},
+		{"file:///srv/git/repo.git", Remote{}, false},
+		{"", Remote{}, false},
+	}
+
+	for _, tc := range tests {
+		remote, ok := ParseRemoteURL(tc.url)
+		if ok != tc.ok || remote != tc.expected {
+			t.Errorf("ParseRemoteURL(%q): expected %+v (%v), got %+v (%v)", tc.url, tc.expected, tc.ok, remote, ok)
+		}
+	}
+}
+
+func TestRemoteOwner_Enterprise(t *testing.T) {
+	dir := initTestRepo(t)
+	commitFile(t, dir, "README.md", "hello")
+	setRemote(t, dir, "git@ghe.example.com:platform/service.git")
+
+	expectedOwner := "platform"
+	if owner := RemoteOwner(dir); owner != expectedOwner {
+		t.Errorf("expected owner %q, got %q", expectedOwner, owner)
+	}
+
+	expectedHost := "ghe.example.com"
+	if host := RemoteHost(dir); host != expectedHost {
+		t.Errorf("expected host %q, got %q", expectedHost, host)
+	}
+}
+
+func TestUnpushedWork(t *testing.T) {
+	clone, _ := initTestRepoWithRemote(t)
+
+	if work, err := UnpushedWork(clone); err != nil || work != "" {
+		t.Fatalf("expected a pushed clone to have no unpushed work, got %q, %v", work, err)
+	}
+
+	cmd := exec.Command("git", "checkout", "-b", "feature")
+	cmd.Dir = clone
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git checkout failed: %s %v", out, err)
+	}
+	commitFile(t, clone, "feature.txt", "local only")
+	os.WriteFile(filepath.Join(clone, "scratch.txt"), []byte("scratch"), 0o644)
+
+	work, err := UnpushedWork(clone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "1 untracked files, 1 unpushed commits"
+	if work != expected {
+		t.Errorf("expected %q, got %q", expected, work)
+	}
+}
+
+func TestUnpushedWork_TagsAndDetachedCommits(t *testing.T) {
+	clone, _ := initTestRepoWithRemote(t)
+
+	for _, args := range [][]string{
+		{"git", "tag", "v1.0.0"},
+		{"git", "checkout", "--detach"},
+	} {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = clone
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v failed: %s %v", args, out, err)
+		}
+	}
+	commitFile(t, clone, "detached.txt", "on no branch")
+
+	work, err := UnpushedWork(clone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "1 unpushed commits, 1 unpushed tags"
+	if work != expected {
+		t.Errorf("expected %q, got %q", expected, work)
+	}
+}
+
+func TestRemoveCheckout_IgnoredFilesDoNotKeepIt(t *testing.T) {
+	clone, _ := initTestRepoWithRemote(t)
+	os.WriteFile(filepath.Join(clone, ".git", "info", "exclude"), []byte(".env\nnode_modules/\n"), 0o644)
+	os.WriteFile(filepath.Join(clone, ".env"), []byte("SECRET=1"), 0o644)
+	os.MkdirAll(filepath.Join(clone, "node_modules", "left-pad"), 0o755)
+	os.WriteFile(filepath.Join(clone, "node_modules", "left-pad", "index.js"), []byte("module.exports = 1"), 0o644)
+
+	if files := IgnoredFiles(clone); len(files) != 1 || files[0] != ".env" {
+		t.Errorf("expected only .env outside ignored directories, got %v", files)
+	}
+
+	work, removed, err := RemoveCheckout(clone, false)
+	if err != nil || !removed || work != "" {
+		t.Fatalf("expected the checkout to be deleted, got %q removed=%v err=%v", work, removed, err)
+	}
+	if _, err := os.Stat(clone); !os.IsNotExist(err) {
+		t.Errorf("expected the checkout to be gone, got %v", err)
+	}
+}
+
+func TestUnpushedWork_UnreachableRemoteTimesOut(t *testing.T) {
+	clone, _ := initTestRepoWithRemote(t)
+	for _, args := range [][]string{
+		{"git", "tag", "v1.0.0"},
+		{"git", "config", "remote.origin.uploadpack", "sleep 10; git-upload-pack"},
+	} {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = clone
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v failed: %s %v", args, out, err)
+		}
+	}
+
+	defer func(timeout time.Duration) { remoteTimeout = timeout }(remoteTimeout)
+	remoteTimeout = 200 * time.Millisecond
+
+	start := time.Now()
+	work, err := UnpushedWork(clone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "1 tags not checked against the remote"
+	if work != expected {
+		t.Errorf("expected %q, got %q", expected, work)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the tag check to give up early, took %v", elapsed)
+	}
+}
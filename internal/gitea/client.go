@@ -0,0 +1,209 @@
+// Package gitea lists repos from a Gitea or Forgejo server through the REST
+// API v1. Forgejo is a Gitea fork with the same API.
+package gitea
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+type ListMode string
+
+const (
+	ModeAuto ListMode = "auto" // an org if one exists, else a user
+	ModeOrg  ListMode = "org"
+	ModeUser ListMode = "user"
+)
+
+func ParseListMode(s string) (ListMode, error) {
+	switch mode := ListMode(strings.ToLower(s)); mode {
+	case "":
+		return ModeAuto, nil
+	case ModeAuto, ModeOrg, ModeUser:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid Gitea listing mode %q (must be auto, org or user)", s)
+}
+
+type Client struct {
+	apiURL     string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(apiURL, token string) *Client {
+	return &Client{
+		apiURL:     NormalizeAPIURL(apiURL),
+		token:      token,
+		httpClient: &http.Client{},
+	}
+}
+
+// NormalizeAPIURL turns a server base URL such as https://git.example.com
+// into its REST endpoint https://git.example.com/api/v1. URLs that already
+// carry a path are left unchanged.
+func NormalizeAPIURL(apiURL string) string {
+	apiURL = strings.TrimRight(apiURL, "/")
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host == "" || parsed.Path != "" {
+		return apiURL
+	}
+	return apiURL + "/api/v1"
+}
+
+// WebHost returns the host that serves git and web traffic for an API URL.
+func WebHost(apiURL string) string {
+	parsed, err := url.Parse(apiURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// repo is the subset of Gitea's repository representation gitall uses.
+type repo struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	FullName      string    `json:"full_name"`
+	Description   string    `json:"description"`
+	CloneURL      string    `json:"clone_url"`
+	SSHURL        string    `json:"ssh_url"`
+	DefaultBranch string    `json:"default_branch"`
+	Topics        []string  `json:"topics"`
+	Language      string    `json:"language"`
+	Private       bool      `json:"private"`
+	Internal      bool      `json:"internal"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Size          int       `json:"size"` // in KB
+	Fork          bool      `json:"fork"`
+	Archived      bool      `json:"archived"`
+	HasWiki       bool      `json:"has_wiki"`
+	Owner         struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+func (r repo) toRepo(ownerType string) provider.Repo {
+	visibility := "public"
+	switch {
+	case r.Private:
+		visibility = "private"
+	case r.Internal:
+		visibility = "internal"
+	}
+
+	return provider.Repo{
+		ID:            r.ID,
+		Name:          r.Name,
+		FullName:      r.FullName,
+		Description:   r.Description,
+		CloneURL:      r.CloneURL,
+		SSHURL:        r.SSHURL,
+		DefaultBranch: r.DefaultBranch,
+		Topics:        r.Topics,
+		Language:      r.Language,
+		Visibility:    visibility,
+		Private:       r.Private,
+		PushedAt:      r.UpdatedAt,
+		Size:          r.Size,
+		Fork:          r.Fork,
+		Archived:      r.Archived,
+		HasWiki:       r.HasWiki,
+		Owner:         provider.Owner{Login: r.Owner.Login, Type: ownerType},
+	}
+}
+
+// ListRepos lists the repos of an org or a user.
+func (c *Client) ListRepos(owner string, mode ListMode) ([]provider.Repo, error) {
+	switch mode {
+	case ModeOrg:
+		return c.listRepos("orgs", owner, "Organization")
+	case ModeUser:
+		return c.listRepos("users", owner, "User")
+	}
+
+	repos, err := c.listRepos("orgs", owner, "Organization")
+	if errors.Is(err, provider.ErrNotFound) {
+		return c.listRepos("users", owner, "User")
+	}
+	return repos, err
+}
+
+func (c *Client) listRepos(kind, owner, ownerType string) ([]provider.Repo, error) {
+	pageURL := fmt.Sprintf("%s/%s/%s/repos?limit=50", c.apiURL, kind, url.PathEscape(owner))
+
+	var all []provider.Repo
+	for pageURL != "" {
+		body, header, err := c.get(pageURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var repos []repo
+		if err := json.Unmarshal(body, &repos); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, r := range repos {
+			all = append(all, r.toRepo(ownerType))
+		}
+
+		pageURL = provider.NextLink(header.Get("Link"))
+	}
+	return all, nil
+}
+
+func (c *Client) get(url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "gitall-cli")
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("calling Gitea API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, newAPIError(resp, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response: %w", err)
+	}
+	return body, resp.Header, nil
+}
+
+func newAPIError(resp *http.Response, url string) *provider.APIError {
+	apiErr := &provider.APIError{
+		Forge:      "Gitea",
+		Kind:       provider.KindForStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		URL:        url,
+		TokenVars:  "GITEA_TOKEN or FORGEJO_TOKEN",
+		Scopes:     "read:repository, read:organization",
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &body) == nil {
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
@@ -0,0 +1,90 @@
+package gitea
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestServer(handler http.HandlerFunc) (*httptest.Server, *Client) {
+	server := httptest.NewServer(handler)
+	client := NewClient(server.URL, "")
+	return server, client
+}
+
+func TestListRepos_OrgFollowsLinkPagination(t *testing.T) {
+	var paths []string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery)
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v1/orgs/mirrors/repos?limit=50&page=2>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"id":1,"name":"linux","fork":true,"private":true,"topics":["kernel"]}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id":2,"name":"git","archived":true}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListRepos("mirrors", ModeOrg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(paths) != 2 || paths[0] != "/api/v1/orgs/mirrors/repos?limit=50" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %d", len(repos))
+	}
+	if !repos[0].Fork || repos[0].RepoVisibility() != "private" || !repos[0].HasTopic("kernel") {
+		t.Errorf("unexpected first repo %+v", repos[0])
+	}
+	if !repos[1].Archived || repos[1].Owner.Type != "Organization" {
+		t.Errorf("unexpected second repo %+v", repos[1])
+	}
+}
+
+func TestListRepos_AutoFallsBackToUser(t *testing.T) {
+	var paths []string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/v1/orgs/jane/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"GetOrgByName"}`)
+			return
+		}
+		fmt.Fprint(w, `[{"name":"dotfiles"}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListRepos("jane", ModeAuto)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(paths) != 2 || paths[1] != "/api/v1/users/jane/repos" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+	if len(repos) != 1 || repos[0].Owner.Type != "User" {
+		t.Errorf("unexpected repos %+v", repos)
+	}
+}
+
+func TestListRepos_SendsToken(t *testing.T) {
+	var receivedAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "secret")
+	if _, err := client.ListRepos("jane", ModeUser); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedAuth != "token secret" {
+		t.Errorf("expected token auth, got %q", receivedAuth)
+	}
+}
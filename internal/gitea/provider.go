@@ -0,0 +1,117 @@
+package gitea
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func init() {
+	provider.Register("gitea", factory("gitea"))
+	provider.Register("forgejo", factory("forgejo"))
+}
+
+// apiProvider puts the Gitea client behind provider.Provider, registered
+// once for Gitea and once for Forgejo. Responses are not cached, so it
+// cannot serve --offline.
+type apiProvider struct {
+	name    string
+	apiURL  string
+	account provider.Account
+	opts    provider.Options
+	mode    ListMode
+}
+
+func factory(name string) provider.Factory {
+	return func(account provider.Account, opts provider.Options) (provider.Provider, error) {
+		if account.APIURL == "" {
+			return nil, fmt.Errorf("%s accounts need an api_url", name)
+		}
+		mode, err := ParseListMode(account.Mode)
+		if err != nil {
+			return nil, err
+		}
+		if account.Team != "" {
+			return nil, fmt.Errorf("%s accounts do not support team listing", name)
+		}
+
+		return &apiProvider{
+			name:    name,
+			apiURL:  NormalizeAPIURL(account.APIURL),
+			account: account,
+			opts:    opts,
+			mode:    mode,
+		}, nil
+	}
+}
+
+// token returns the account's token, falling back to GITEA_TOKEN or
+// FORGEJO_TOKEN.
+func (p *apiProvider) token() string {
+	token, _ := p.Credential()
+	return token
+}
+
+func (p *apiProvider) Credential() (string, string) {
+	if p.account.Token != "" {
+		return p.account.Token, "config"
+	}
+	env := strings.ToUpper(p.name) + "_TOKEN"
+	if token := os.Getenv(env); token != "" {
+		return token, env
+	}
+	return "", ""
+}
+
+func (p *apiProvider) Name() string {
+	return p.name
+}
+
+func (p *apiProvider) Host() string {
+	return WebHost(p.apiURL)
+}
+
+func (p *apiProvider) ListRepos(filter provider.Filter) ([]provider.Repo, error) {
+	if p.opts.Offline {
+		return nil, fmt.Errorf("%s: %w — %s responses are not cached, run again without --offline", p.apiURL, provider.ErrNotCached, p.name)
+	}
+
+	repos, err := NewClient(p.apiURL, p.token()).ListRepos(p.account.Owner, p.mode)
+	if err != nil {
+		return nil, err
+	}
+	return provider.FilterRepos(repos, filter), nil
+}
+
+func (p *apiProvider) CloneURL(repo provider.Repo, protocol string) string {
+	switch protocol {
+	case "https":
+		if repo.CloneURL != "" {
+			return repo.CloneURL
+		}
+		return "https://" + p.Host() + "/" + p.account.Owner + "/" + repo.Name + ".git"
+	default:
+		if repo.SSHURL != "" {
+			return repo.SSHURL
+		}
+		return "git@" + p.Host() + ":" + p.account.Owner + "/" + repo.Name + ".git"
+	}
+}
+
+// WikiURL returns the clone URL of a repo's wiki, which Gitea serves as a
+// separate repo next to the main one.
+func (p *apiProvider) WikiURL(repo provider.Repo, protocol string) string {
+	return strings.TrimSuffix(p.CloneURL(repo, protocol), ".git") + ".wiki.git"
+}
+
+// ParseRemote accepts owner/name remotes on the provider's host.
+func (p *apiProvider) ParseRemote(remoteURL string) (git.Remote, bool) {
+	remote, ok := git.ParseRemoteURL(remoteURL)
+	if !ok || !strings.EqualFold(remote.Host, p.Host()) || strings.Contains(remote.Owner, "/") {
+		return git.Remote{}, false
+	}
+	return remote, true
+}
@@ -0,0 +1,79 @@
+package gitea
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func TestProvider_ListsReposThroughRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"tool","clone_url":"https://forge.example.com/mirrors/tool.git"},{"name":"old","archived":true}]`)
+	}))
+	defer server.Close()
+
+	p, err := provider.New("forgejo", provider.Account{Owner: "mirrors", APIURL: server.URL}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repos, err := p.ListRepos(provider.Filter{NoArchived: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Name() != "forgejo" || len(repos) != 1 {
+		t.Fatalf("expected only tool from forgejo, got %s %+v", p.Name(), repos)
+	}
+	if got := p.CloneURL(repos[0], "https"); got != "https://forge.example.com/mirrors/tool.git" {
+		t.Errorf("unexpected clone URL %q", got)
+	}
+}
+
+func TestProvider_ParseRemoteMatchesHost(t *testing.T) {
+	p, err := provider.New("gitea", provider.Account{Owner: "me", APIURL: "https://git.example.com"}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remote, ok := p.ParseRemote("ssh://git@git.example.com:2222/team/tool.git")
+	if !ok || remote.Owner != "team" || remote.Name != "tool" {
+		t.Errorf("expected team/tool, got %+v (ok=%v)", remote, ok)
+	}
+	if _, ok := p.ParseRemote("git@github.com:team/tool.git"); ok {
+		t.Error("expected a remote on another host not to match")
+	}
+	if got := p.CloneURL(provider.Repo{Name: "tool"}, "ssh"); got != "git@git.example.com:me/tool.git" {
+		t.Errorf("unexpected clone URL %q", got)
+	}
+}
+
+func TestProvider_RequiresAPIURL(t *testing.T) {
+	if _, err := provider.New("gitea", provider.Account{Owner: "me"}, provider.Options{}); err == nil {
+		t.Fatal("expected an error without api_url, got nil")
+	}
+}
+
+func TestProvider_CredentialReadsItsOwnVariable(t *testing.T) {
+	t.Setenv("GITEA_TOKEN", "")
+	t.Setenv("FORGEJO_TOKEN", "fj_token")
+
+	for _, tc := range []struct {
+		name, token, source string
+	}{
+		{"forgejo", "fj_token", "FORGEJO_TOKEN"},
+		{"gitea", "", ""},
+	} {
+		p, err := provider.New(tc.name, provider.Account{Owner: "acme", APIURL: "https://git.example.com"}, provider.Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		token, source := p.(provider.CredentialProvider).Credential()
+		if token != tc.token || source != tc.source {
+			t.Errorf("%s: expected %q from %q, got %q from %q", tc.name, tc.token, tc.source, token, source)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type CacheMode int
+
+const (
+	CacheRevalidate CacheMode = iota // send conditional requests, serve 304s from cache
+	CacheRefresh                     // skip cached entries but store fresh responses
+	CacheOffline                     // answer from the cache alone, never touch the network
+)
+
+// Cache stores GitHub API responses on disk together with their ETag and
+// Last-Modified validators. Entries are keyed by URL and token so that
+// responses fetched with one token are never served to another.
+type Cache struct {
+	dir  string
+	mode CacheMode
+}
+
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Link         string    `json:"link,omitempty"`
+	Body         []byte    `json:"body"`
+	StoredAt     time.Time `json:"stored_at"`
+}
+
+func NewCache(dir string, mode CacheMode) *Cache {
+	return &Cache{dir: dir, mode: mode}
+}
+
+func DefaultCacheDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".gitall", "cache")
+}
+
+func (c *Cache) Mode() CacheMode {
+	return c.mode
+}
+
+func (c *Cache) key(url, token string) string {
+	tokenHash := sha256.Sum256([]byte(token))
+	sum := sha256.Sum256([]byte(url + "\x00" + hex.EncodeToString(tokenHash[:])))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+func (c *Cache) get(url, token string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(c.key(url, token)))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *Cache) put(url, token string, header http.Header, body []byte) error {
+	entry := cacheEntry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Link:         header.Get("Link"),
+		Body:         body,
+		StoredAt:     time.Now().UTC(),
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding cache entry: %w", err)
+	}
+
+	path := c.path(c.key(url, token))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	return nil
+}
+
+func (e *cacheEntry) header() http.Header {
+	header := http.Header{}
+	if e.Link != "" {
+		header.Set("Link", e.Link)
+	}
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	if e.LastModified != "" {
+		header.Set("Last-Modified", e.LastModified)
+	}
+	return header
+}
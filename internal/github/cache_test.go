@@ -0,0 +1,137 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCache_ServesNotModifiedFromCache(t *testing.T) {
+	requestCount := 0
+	var receivedIfNoneMatch string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		receivedIfNoneMatch = r.Header.Get("If-None-Match")
+		if receivedIfNoneMatch == `"abc"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		fmt.Fprint(w, `[{"name":"repo1"}]`)
+	})
+	defer server.Close()
+	client.SetCache(NewCache(t.TempDir(), CacheRevalidate))
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err != nil {
+		t.Fatalf("unexpected error on first request: %v", err)
+	}
+
+	repos, err := client.ListRepos("testuser", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error on second request: %v", err)
+	}
+
+	expectedHeader := `"abc"`
+	if receivedIfNoneMatch != expectedHeader {
+		t.Errorf("expected If-None-Match %q, got %q", expectedHeader, receivedIfNoneMatch)
+	}
+
+	expectedCount := 1
+	if len(repos) != expectedCount {
+		t.Fatalf("expected %d repo from cache, got %d", expectedCount, len(repos))
+	}
+
+	expectedRequests := 2
+	if requestCount != expectedRequests {
+		t.Errorf("expected %d requests, got %d", expectedRequests, requestCount)
+	}
+}
+
+func TestCache_SendsIfModifiedSince(t *testing.T) {
+	var receivedIfModifiedSince string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedIfModifiedSince = r.Header.Get("If-Modified-Since")
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		fmt.Fprint(w, `[]`)
+	})
+	defer server.Close()
+	client.SetCache(NewCache(t.TempDir(), CacheRevalidate))
+
+	client.ListRepos("testuser", ListOptions{})
+	client.ListRepos("testuser", ListOptions{})
+
+	expected := "Mon, 01 Jan 2024 00:00:00 GMT"
+	if receivedIfModifiedSince != expected {
+		t.Errorf("expected If-Modified-Since %q, got %q", expected, receivedIfModifiedSince)
+	}
+}
+
+func TestCache_RefreshSkipsConditionalHeaders(t *testing.T) {
+	var receivedIfNoneMatch string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedIfNoneMatch = r.Header.Get("If-None-Match")
+		w.Header().Set("ETag", `"abc"`)
+		fmt.Fprint(w, `[]`)
+	})
+	defer server.Close()
+	dir := t.TempDir()
+
+	client.SetCache(NewCache(dir, CacheRevalidate))
+	client.ListRepos("testuser", ListOptions{})
+
+	client.SetCache(NewCache(dir, CacheRefresh))
+	client.ListRepos("testuser", ListOptions{})
+
+	if receivedIfNoneMatch != "" {
+		t.Errorf("expected no If-None-Match with refresh, got %q", receivedIfNoneMatch)
+	}
+}
+
+func TestCache_OfflineAnswersFromCacheAlone(t *testing.T) {
+	requestCount := 0
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		fmt.Fprint(w, `[{"name":"repo1"},{"name":"repo2"}]`)
+	})
+	defer server.Close()
+	dir := t.TempDir()
+
+	client.SetCache(NewCache(dir, CacheRevalidate))
+	client.ListRepos("testuser", ListOptions{})
+
+	client.SetCache(NewCache(dir, CacheOffline))
+	repos, err := client.ListRepos("testuser", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedCount := 2
+	if len(repos) != expectedCount {
+		t.Errorf("expected %d repos, got %d", expectedCount, len(repos))
+	}
+
+	expectedRequests := 1
+	if requestCount != expectedRequests {
+		t.Errorf("expected %d request, got %d", expectedRequests, requestCount)
+	}
+}
+
+func TestCache_OfflineMissReturnsError(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	defer server.Close()
+	client.SetCache(NewCache(t.TempDir(), CacheOffline))
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err == nil {
+		t.Fatal("expected error for offline cache miss, got nil")
+	}
+}
+
+func TestCache_KeysByToken(t *testing.T) {
+	cache := NewCache(t.TempDir(), CacheRevalidate)
+
+	if cache.key("https://api.github.com/x", "token-a") == cache.key("https://api.github.com/x", "token-b") {
+		t.Error("expected different cache keys for different tokens")
+	}
+}
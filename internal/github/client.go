@@ -7,32 +7,84 @@ import (
 	"net/http"
 	"net/url"
 	"path"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+const (
+	defaultAPIURL = "https://api.github.com"
+	defaultHost   = "github.com"
+)
+
+// Repo and Owner are the provider types, which decode GitHub's REST
+// responses directly.
+type (
+	Repo  = provider.Repo
+	Owner = provider.Owner
 )
 
-const defaultAPIURL = "https://api.github.com"
+type ListMode string
 
-type Repo struct {
-	Name     string `json:"name"`
-	FullName string `json:"full_name"`
-	CloneURL string `json:"clone_url"`
-	SSHURL   string `json:"ssh_url"`
-	Fork     bool   `json:"fork"`
-	Archived bool   `json:"archived"`
+const (
+	ModeAuto          ListMode = "auto"
+	ModeUser          ListMode = "user"
+	ModeOrg           ListMode = "org"
+	ModeTeam          ListMode = "team"
+	ModeAuthenticated ListMode = "authenticated"
+)
+
+var validModes = map[ListMode]bool{
+	ModeAuto:          true,
+	ModeUser:          true,
+	ModeOrg:           true,
+	ModeTeam:          true,
+	ModeAuthenticated: true,
+}
+
+func ParseListMode(s string) (ListMode, error) {
+	if s == "" {
+		return ModeAuto, nil
+	}
+	mode := ListMode(strings.ToLower(s))
+	if !validModes[mode] {
+		return "", fmt.Errorf("invalid listing mode %q (must be auto, user, org, team or authenticated)", s)
+	}
+	return mode, nil
 }
 
 type ListOptions struct {
 	NoForks    bool
 	NoArchived bool
 	Filter     string // glob-style pattern (e.g. "prefix-*")
+
+	Mode        ListMode // defaults to ModeUser when empty
+	Team        string   // team slug, required for ModeTeam
+	Type        string   // org repo type: all, public, private, forks, sources, member
+	Affiliation string   // authenticated user: owner, collaborator, organization_member
+	Visibility  string   // all, public, private or internal; sent as a query for ModeAuthenticated
+
+	Topics      []string  // keep repos tagged with any of these topics
+	Language    string    // primary language, case-insensitive
+	PushedAfter time.Time // keep repos pushed at or after this time
+	MaxSize     int       // in KB, 0 for no limit
 }
 
 type Client struct {
 	apiURL     string
 	token      string
 	httpClient *http.Client
+	cache      *Cache
+	retry      RetryPolicy
+	sleep      func(time.Duration)
+	now        func() time.Time
+
+	mu            sync.Mutex
+	lastRateLimit *RateLimit
 }
 
 func NewClient(apiURL, token string) *Client {
@@ -43,16 +95,88 @@ func NewClient(apiURL, token string) *Client {
 		apiURL:     strings.TrimRight(apiURL, "/"),
 		token:      token,
 		httpClient: &http.Client{},
+		retry:      DefaultRetryPolicy(),
+		sleep:      time.Sleep,
+		now:        time.Now,
+	}
+}
+
+// NormalizeAPIURL turns a GitHub Enterprise Server base URL such as
+// https://ghe.example.com into its REST endpoint https://ghe.example.com/api/v3.
+// The public API URL and URLs that already carry a path are left unchanged.
+func NormalizeAPIURL(apiURL string) string {
+	if apiURL == "" {
+		return defaultAPIURL
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host == "" || parsed.Host == "api.github.com" {
+		return apiURL
+	}
+	if parsed.Path == "" {
+		return apiURL + "/api/v3"
+	}
+	return apiURL
+}
+
+// WebHost returns the host that serves git and web traffic for an API URL:
+// github.com for the public API, otherwise the API URL's own host.
+func WebHost(apiURL string) string {
+	if apiURL == "" {
+		return defaultHost
+	}
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host == "" || parsed.Host == "api.github.com" {
+		return defaultHost
 	}
+	return parsed.Host
+}
+
+func (c *Client) Host() string {
+	return WebHost(c.apiURL)
+}
+
+// SetCache enables on-disk response caching. Pass nil to disable it.
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
 }
 
 func (c *Client) ListRepos(username string, opts ListOptions) ([]Repo, error) {
+	mode, err := c.resolveMode(username, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	var allRepos []Repo
+	if c.useGraphQL(mode, opts) {
+		allRepos, err = c.listReposGraphQL(username)
+	} else {
+		allRepos, err = c.listReposREST(username, mode, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.FilterRepos(allRepos, opts.filter()), nil
+}
+
+func (c *Client) listReposREST(username string, mode ListMode, opts ListOptions) ([]Repo, error) {
+	basePath, err := listPath(username, mode, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	var allRepos []Repo
 	page := 1
 
 	for {
-		url := fmt.Sprintf("%s/users/%s/repos?per_page=100&page=%d", c.apiURL, username, page)
-		repos, nextPage, err := c.fetchPage(url)
+		query := listQuery(mode, opts)
+		query.Set("per_page", "100")
+		query.Set("page", strconv.Itoa(page))
+
+		pageURL := fmt.Sprintf("%s%s?%s", c.apiURL, basePath, query.Encode())
+		repos, nextPage, err := c.fetchPage(pageURL)
 		if err != nil {
 			return nil, err
 		}
@@ -65,13 +189,213 @@ func (c *Client) ListRepos(username string, opts ListOptions) ([]Repo, error) {
 		page = nextPage
 	}
 
-	return filterRepos(allRepos, opts), nil
+	return allRepos, nil
+}
+
+func (c *Client) GetOwner(name string) (*Owner, error) {
+	var owner Owner
+	if err := c.getJSON(fmt.Sprintf("%s/users/%s", c.apiURL, url.PathEscape(name)), &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// GetRepo looks up a repo by owner and name. GitHub redirects requests for
+// renamed or transferred repos, so the result carries the current name.
+func (c *Client) GetRepo(owner, name string) (*Repo, error) {
+	var repo Repo
+	if err := c.getJSON(fmt.Sprintf("%s/repos/%s/%s", c.apiURL, url.PathEscape(owner), url.PathEscape(name)), &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+func (c *Client) GetRepoByID(id int64) (*Repo, error) {
+	var repo Repo
+	if err := c.getJSON(fmt.Sprintf("%s/repositories/%d", c.apiURL, id), &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+func (c *Client) AuthenticatedUser() (*Owner, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("a token is required to look up the authenticated user")
+	}
+	var owner Owner
+	if err := c.getJSON(c.apiURL+"/user", &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// TokenInfo is what GitHub reports about the client's token.
+type TokenInfo struct {
+	Login string
+	// Scopes are a classic token's OAuth scopes. They are nil for
+	// fine-grained and app tokens, which GitHub does not report scopes for.
+	Scopes []string
+}
+
+// HasScope reports whether a classic token was granted scope.
+func (t TokenInfo) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// TokenInfo looks up the token's user and, for classic tokens, the scopes
+// GitHub lists in the X-OAuth-Scopes header. The cache keeps no such header,
+// so this always asks the API.
+func (c *Client) TokenInfo() (*TokenInfo, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("a token is required to look up the authenticated user")
+	}
+
+	url := c.apiURL + "/user"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("User-Agent", "gitall-cli")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+	c.recordRateLimit(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, url)
+	}
+
+	var owner Owner
+	if err := json.NewDecoder(resp.Body).Decode(&owner); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	info := &TokenInfo{Login: owner.Login}
+	if values, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		info.Scopes = []string{}
+		for _, scope := range strings.Split(strings.Join(values, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+	return info, nil
+}
+
+// resolveMode turns ModeAuto into a concrete mode by asking the API whether
+// the account is a user or an organisation. When the account is the token's
+// own user, /user/repos is used so private and collaborator repos are seen.
+func (c *Client) resolveMode(username string, mode ListMode) (ListMode, error) {
+	if mode == "" {
+		return ModeUser, nil
+	}
+	if mode != ModeAuto {
+		return mode, nil
+	}
+
+	owner, err := c.GetOwner(username)
+	if err != nil {
+		return "", err
+	}
+	if owner.Type == "Organization" {
+		return ModeOrg, nil
+	}
+
+	if c.token != "" {
+		if me, err := c.AuthenticatedUser(); err == nil && strings.EqualFold(me.Login, username) {
+			return ModeAuthenticated, nil
+		}
+	}
+	return ModeUser, nil
+}
+
+func listPath(username string, mode ListMode, opts ListOptions) (string, error) {
+	switch mode {
+	case ModeUser:
+		return "/users/" + url.PathEscape(username) + "/repos", nil
+	case ModeOrg:
+		return "/orgs/" + url.PathEscape(username) + "/repos", nil
+	case ModeTeam:
+		if opts.Team == "" {
+			return "", fmt.Errorf("team mode requires a team slug")
+		}
+		return "/orgs/" + url.PathEscape(username) + "/teams/" + url.PathEscape(opts.Team) + "/repos", nil
+	case ModeAuthenticated:
+		return "/user/repos", nil
+	default:
+		return "", fmt.Errorf("unsupported listing mode %q", mode)
+	}
+}
+
+func listQuery(mode ListMode, opts ListOptions) url.Values {
+	query := url.Values{}
+	switch mode {
+	case ModeOrg:
+		if opts.Type != "" {
+			query.Set("type", opts.Type)
+		}
+	case ModeAuthenticated:
+		if opts.Affiliation != "" {
+			query.Set("affiliation", opts.Affiliation)
+		}
+		// The API has no internal filter; FilterRepos handles that case.
+		if opts.Visibility != "" && opts.Visibility != "internal" {
+			query.Set("visibility", opts.Visibility)
+		}
+	}
+	return query
 }
 
 func (c *Client) fetchPage(url string) ([]Repo, int, error) {
+	body, header, err := c.get(url)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var repos []Repo
+	if err := json.Unmarshal(body, &repos); err != nil {
+		return nil, 0, fmt.Errorf("parsing response: %w", err)
+	}
+
+	nextPage := parseNextPage(header.Get("Link"))
+	return repos, nextPage, nil
+}
+
+func (c *Client) getJSON(url string, v any) error {
+	body, _, err := c.get(url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) get(url string) ([]byte, http.Header, error) {
+	var cached *cacheEntry
+	if c.cache != nil {
+		entry, ok := c.cache.get(url, c.token)
+		switch c.cache.mode {
+		case CacheOffline:
+			if !ok {
+				return nil, nil, fmt.Errorf("%s: %w — run again without --offline", url, ErrNotCached)
+			}
+			return entry.Body, entry.header(), nil
+		case CacheRevalidate:
+			if ok {
+				cached = entry
+			}
+		}
+	}
+
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("creating request: %w", err)
+		return nil, nil, fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
@@ -79,35 +403,40 @@ func (c *Client) fetchPage(url string) ([]Repo, int, error) {
 	if c.token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("fetching repos: %w", err)
+		return nil, nil, fmt.Errorf("calling GitHub API: %w", err)
 	}
 	defer resp.Body.Close()
+	c.recordRateLimit(resp.Header)
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, 0, fmt.Errorf("user or organisation not found")
-	}
-	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
-		return nil, 0, fmt.Errorf("GitHub API rate limit exceeded — use a token to increase limits")
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached.Body, cached.header(), nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, 0, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+		return nil, nil, newAPIError(resp, url)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, fmt.Errorf("reading response: %w", err)
+		return nil, nil, fmt.Errorf("reading response: %w", err)
 	}
 
-	var repos []Repo
-	if err := json.Unmarshal(body, &repos); err != nil {
-		return nil, 0, fmt.Errorf("parsing response: %w", err)
+	if c.cache != nil {
+		// A failed cache write only costs a full download next time.
+		_ = c.cache.put(url, c.token, resp.Header, body)
 	}
 
-	nextPage := parseNextPage(resp.Header.Get("Link"))
-	return repos, nextPage, nil
+	return body, resp.Header, nil
 }
 
 func parseNextPage(linkHeader string) int {
@@ -142,54 +471,46 @@ func parseNextPage(linkHeader string) int {
 	return 0
 }
 
-func filterRepos(repos []Repo, opts ListOptions) []Repo {
-	var filtered []Repo
-
-	var pattern *regexp.Regexp
-	if opts.Filter != "" {
-		regexStr := "^" + globToRegex(opts.Filter) + "$"
-		pattern, _ = regexp.Compile(regexStr)
-	}
-
-	for _, repo := range repos {
-		if opts.NoForks && repo.Fork {
-			continue
-		}
-		if opts.NoArchived && repo.Archived {
-			continue
-		}
-		if pattern != nil && !pattern.MatchString(repo.Name) {
-			continue
-		}
-		filtered = append(filtered, repo)
+// filter returns the options' name and metadata filters.
+func (o ListOptions) filter() provider.Filter {
+	return provider.Filter{
+		NoForks:     o.NoForks,
+		NoArchived:  o.NoArchived,
+		Pattern:     o.Filter,
+		Topics:      o.Topics,
+		Language:    o.Language,
+		Visibility:  o.Visibility,
+		PushedAfter: o.PushedAfter,
+		MaxSize:     o.MaxSize,
 	}
-
-	return filtered
 }
 
-func globToRegex(glob string) string {
-	var result strings.Builder
-	for _, ch := range glob {
-		switch ch {
-		case '*':
-			result.WriteString(".*")
-		case '?':
-			result.WriteString(".")
-		case '.', '(', ')', '+', '|', '^', '$', '[', ']', '{', '}', '\\':
-			result.WriteRune('\\')
-			result.WriteRune(ch)
-		default:
-			result.WriteRune(ch)
-		}
-	}
-	return result.String()
+func CloneURL(repo Repo, protocol, username string) string {
+	return CloneURLForHost(repo, protocol, username, defaultHost)
 }
 
-func CloneURL(repo Repo, protocol, username string) string {
+// CloneURLForHost builds a clone URL on the given host. HTTPS prefers the
+// URL reported by the API, which already points at the right host. The repo's
+// own owner wins over username, since an authenticated listing includes
+// repos of orgs and other users.
+func CloneURLForHost(repo Repo, protocol, username, host string) string {
+	if host == "" {
+		host = defaultHost
+	}
+	owner := repo.ListedOwner(username)
 	switch protocol {
 	case "https":
-		return repo.CloneURL
+		if repo.CloneURL != "" {
+			return repo.CloneURL
+		}
+		return "https://" + host + "/" + path.Join(owner, repo.Name) + ".git"
 	default:
-		return "git@github.com:" + path.Join(username, repo.Name) + ".git"
+		return "git@" + host + ":" + path.Join(owner, repo.Name) + ".git"
 	}
 }
+
+// WikiURL returns the clone URL of a repo's wiki, which GitHub serves as a
+// separate repo next to the main one.
+func WikiURL(repo Repo, protocol, username, host string) string {
+	return strings.TrimSuffix(CloneURLForHost(repo, protocol, username, host), ".git") + ".wiki.git"
+}
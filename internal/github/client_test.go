@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"regexp"
 	"testing"
+	"time"
 )
 
 func newTestServer(handler http.HandlerFunc) (*httptest.Server, *Client) {
@@ -169,6 +169,76 @@ func TestListRepos_FiltersbyNamePattern(t *testing.T) {
 	}
 }
 
+func TestListRepos_DecodesMetadata(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"api","description":"Payments API","default_branch":"main",
+			"topics":["team-payments","go"],"language":"Go","visibility":"internal","private":true,
+			"pushed_at":"2026-03-01T12:00:00Z","size":2048}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListRepos("testuser", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 {
+		t.Fatalf("expected 1 repo, got %d", len(repos))
+	}
+
+	repo := repos[0]
+	if repo.DefaultBranch != "main" || repo.Language != "Go" || repo.Description != "Payments API" {
+		t.Errorf("unexpected metadata: %+v", repo)
+	}
+	if !repo.HasTopic("Team-Payments") {
+		t.Errorf("expected topic team-payments, got %v", repo.Topics)
+	}
+	if repo.RepoVisibility() != "internal" {
+		t.Errorf("expected visibility internal, got %q", repo.RepoVisibility())
+	}
+	expectedPushed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if !repo.PushedAt.Equal(expectedPushed) {
+		t.Errorf("expected pushed_at %v, got %v", expectedPushed, repo.PushedAt)
+	}
+	if repo.Size != 2048 {
+		t.Errorf("expected size 2048, got %d", repo.Size)
+	}
+}
+
+func TestGetRepo_DecodesParent(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"fork","fork":true,"parent":{"name":"upstream","full_name":"other/upstream"}}`)
+	})
+	defer server.Close()
+
+	repo, err := client.GetRepo("me", "fork")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Parent == nil || repo.Parent.FullName != "other/upstream" {
+		t.Errorf("expected parent other/upstream, got %+v", repo.Parent)
+	}
+}
+
+func TestListRepos_InternalVisibilityIsFilteredLocally(t *testing.T) {
+	var receivedVisibility string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedVisibility = r.URL.Query().Get("visibility")
+		fmt.Fprint(w, `[{"name":"inner","visibility":"internal"},{"name":"outer","visibility":"public"}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListRepos("me", ListOptions{Mode: ModeAuthenticated, Visibility: "internal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receivedVisibility != "" {
+		t.Errorf("expected no visibility query, got %q", receivedVisibility)
+	}
+	if len(repos) != 1 || repos[0].Name != "inner" {
+		t.Errorf("expected only inner, got %+v", repos)
+	}
+}
+
 func TestParseNextPage_WithNextLink(t *testing.T) {
 	header := `<https://api.github.com/users/test/repos?per_page=100&page=2>; rel="next", <https://api.github.com/users/test/repos?per_page=100&page=5>; rel="last"`
 
@@ -220,29 +290,304 @@ func TestCloneURL_HTTPS(t *testing.T) {
 	}
 }
 
-func TestGlobToRegex(t *testing.T) {
+func TestListRepos_OrgModeUsesOrgEndpointWithType(t *testing.T) {
+	var receivedPath, receivedType string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedType = r.URL.Query().Get("type")
+		fmt.Fprint(w, `[{"name":"private-service"}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListRepos("myorg", ListOptions{Mode: ModeOrg, Type: "private"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedPath := "/orgs/myorg/repos"
+	if receivedPath != expectedPath {
+		t.Errorf("expected path %q, got %q", expectedPath, receivedPath)
+	}
+
+	expectedType := "private"
+	if receivedType != expectedType {
+		t.Errorf("expected type %q, got %q", expectedType, receivedType)
+	}
+
+	expectedCount := 1
+	if len(repos) != expectedCount {
+		t.Errorf("expected %d repo, got %d", expectedCount, len(repos))
+	}
+}
+
+func TestListRepos_TeamModeUsesTeamEndpoint(t *testing.T) {
+	var receivedPath string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		fmt.Fprint(w, `[]`)
+	})
+	defer server.Close()
+
+	if _, err := client.ListRepos("myorg", ListOptions{Mode: ModeTeam, Team: "payments"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedPath := "/orgs/myorg/teams/payments/repos"
+	if receivedPath != expectedPath {
+		t.Errorf("expected path %q, got %q", expectedPath, receivedPath)
+	}
+}
+
+func TestListRepos_TeamModeRequiresTeam(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	defer server.Close()
+
+	_, err := client.ListRepos("myorg", ListOptions{Mode: ModeTeam})
+	if err == nil {
+		t.Fatal("expected error for missing team slug, got nil")
+	}
+}
+
+func TestListRepos_AuthenticatedModeSendsFilters(t *testing.T) {
+	var receivedPath, receivedAffiliation, receivedVisibility string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedAffiliation = r.URL.Query().Get("affiliation")
+		receivedVisibility = r.URL.Query().Get("visibility")
+		fmt.Fprint(w, `[]`)
+	})
+	defer server.Close()
+
+	opts := ListOptions{Mode: ModeAuthenticated, Affiliation: "owner,collaborator", Visibility: "private"}
+	if _, err := client.ListRepos("me", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedPath := "/user/repos"
+	if receivedPath != expectedPath {
+		t.Errorf("expected path %q, got %q", expectedPath, receivedPath)
+	}
+
+	expectedAffiliation := "owner,collaborator"
+	if receivedAffiliation != expectedAffiliation {
+		t.Errorf("expected affiliation %q, got %q", expectedAffiliation, receivedAffiliation)
+	}
+
+	expectedVisibility := "private"
+	if receivedVisibility != expectedVisibility {
+		t.Errorf("expected visibility %q, got %q", expectedVisibility, receivedVisibility)
+	}
+}
+
+func TestListRepos_AutoModeDetectsOrganisation(t *testing.T) {
+	var listPath string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/myorg" {
+			fmt.Fprint(w, `{"login":"myorg","type":"Organization"}`)
+			return
+		}
+		listPath = r.URL.Path
+		fmt.Fprint(w, `[]`)
+	})
+	defer server.Close()
+
+	if _, err := client.ListRepos("myorg", ListOptions{Mode: ModeAuto}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedPath := "/orgs/myorg/repos"
+	if listPath != expectedPath {
+		t.Errorf("expected path %q, got %q", expectedPath, listPath)
+	}
+}
+
+func TestListRepos_AutoModeUsesAuthenticatedEndpointForTokenOwner(t *testing.T) {
+	var listPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/me":
+			fmt.Fprint(w, `{"login":"Me","type":"User"}`)
+		case "/user":
+			fmt.Fprint(w, `{"login":"Me","type":"User"}`)
+		default:
+			listPath = r.URL.Path
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "ghp_testtoken123")
+	if _, err := client.ListRepos("me", ListOptions{Mode: ModeAuto}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedPath := "/user/repos"
+	if listPath != expectedPath {
+		t.Errorf("expected path %q, got %q", expectedPath, listPath)
+	}
+}
+
+func TestParseListMode(t *testing.T) {
 	tests := []struct {
-		glob     string
 		input    string
-		expected bool
+		expected ListMode
+		wantErr  bool
 	}{
-		{"api-*", "api-gateway", true},
-		{"api-*", "web-frontend", false},
-		{"*-service", "auth-service", true},
-		{"*-service", "auth-controller", false},
-		{"exact", "exact", true},
-		{"exact", "notexact", false},
+		{"", ModeAuto, false},
+		{"org", ModeOrg, false},
+		{"Team", ModeTeam, false},
+		{"enterprise", "", true},
 	}
 
 	for _, tc := range tests {
-		regexStr := "^" + globToRegex(tc.glob) + "$"
-		re, err := regexp.Compile(regexStr)
-		if err != nil {
-			t.Fatalf("invalid regex %q from glob %q: %v", regexStr, tc.glob, err)
+		mode, err := ParseListMode(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("ParseListMode(%q): unexpected error state: %v", tc.input, err)
 		}
-		result := re.MatchString(tc.input)
-		if result != tc.expected {
-			t.Errorf("glob %q against %q: expected %v, got %v", tc.glob, tc.input, tc.expected, result)
+		if mode != tc.expected {
+			t.Errorf("ParseListMode(%q): expected %q, got %q", tc.input, tc.expected, mode)
 		}
 	}
 }
+
+func TestGetRepoByID_UsesRepositoriesEndpoint(t *testing.T) {
+	var receivedPath string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		fmt.Fprint(w, `{"id":42,"name":"new-name","full_name":"NewOrg/new-name","owner":{"login":"NewOrg"}}`)
+	})
+	defer server.Close()
+
+	repo, err := client.GetRepoByID(42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedPath := "/repositories/42"
+	if receivedPath != expectedPath {
+		t.Errorf("expected path %q, got %q", expectedPath, receivedPath)
+	}
+
+	expectedOwner := "NewOrg"
+	if repo.Owner.Login != expectedOwner {
+		t.Errorf("expected owner %q, got %q", expectedOwner, repo.Owner.Login)
+	}
+}
+
+func TestGetRepo_FollowsRenameRedirect(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/OldOrg/old-name" {
+			http.Redirect(w, r, "/repositories/42", http.StatusMovedPermanently)
+			return
+		}
+		fmt.Fprint(w, `{"id":42,"name":"new-name","owner":{"login":"NewOrg"}}`)
+	})
+	defer server.Close()
+
+	repo, err := client.GetRepo("OldOrg", "old-name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedName := "new-name"
+	if repo.Name != expectedName {
+		t.Errorf("expected name %q, got %q", expectedName, repo.Name)
+	}
+}
+
+func TestCloneURLForHost_Enterprise(t *testing.T) {
+	repo := Repo{Name: "myrepo"}
+
+	tests := []struct {
+		protocol string
+		expected string
+	}{
+		{"ssh", "git@ghe.example.com:team/myrepo.git"},
+		{"https", "https://ghe.example.com/team/myrepo.git"},
+	}
+
+	for _, tc := range tests {
+		if result := CloneURLForHost(repo, tc.protocol, "team", "ghe.example.com"); result != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.protocol, tc.expected, result)
+		}
+	}
+}
+
+func TestWikiURL(t *testing.T) {
+	repo := Repo{Name: "docs", CloneURL: "https://github.com/me/docs.git"}
+
+	if got := WikiURL(repo, "https", "me", "github.com"); got != "https://github.com/me/docs.wiki.git" {
+		t.Errorf("unexpected https wiki URL %q", got)
+	}
+	if got := WikiURL(repo, "ssh", "me", "ghe.example.com"); got != "git@ghe.example.com:me/docs.wiki.git" {
+		t.Errorf("unexpected ssh wiki URL %q", got)
+	}
+}
+
+func TestNormalizeAPIURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "https://api.github.com"},
+		{"https://api.github.com/", "https://api.github.com"},
+		{"https://ghe.example.com", "https://ghe.example.com/api/v3"},
+		{"https://ghe.example.com/api/v3/", "https://ghe.example.com/api/v3"},
+	}
+
+	for _, tc := range tests {
+		if result := NormalizeAPIURL(tc.input); result != tc.expected {
+			t.Errorf("NormalizeAPIURL(%q): expected %q, got %q", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestWebHost(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "github.com"},
+		{"https://api.github.com", "github.com"},
+		{"https://ghe.example.com/api/v3", "ghe.example.com"},
+	}
+
+	for _, tc := range tests {
+		if result := WebHost(tc.input); result != tc.expected {
+			t.Errorf("WebHost(%q): expected %q, got %q", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestTokenInfo_ReadsClassicScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-OAuth-Scopes", "repo, read:org")
+		fmt.Fprint(w, `{"login":"jane"}`)
+	}))
+	defer server.Close()
+
+	info, err := NewClient(server.URL, "ghp_secret").TokenInfo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Login != "jane" || !info.HasScope("repo") || !info.HasScope("read:org") || info.HasScope("admin:org") {
+		t.Errorf("unexpected token info %+v", info)
+	}
+}
+
+func TestTokenInfo_FineGrainedTokenHasNoScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"jane"}`)
+	}))
+	defer server.Close()
+
+	info, err := NewClient(server.URL, "github_pat_secret").TokenInfo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Scopes != nil {
+		t.Errorf("expected nil scopes, got %v", info.Scopes)
+	}
+}
@@ -0,0 +1,164 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+var (
+	ErrUnauthorized = provider.ErrUnauthorized
+	ErrSSORequired  = errors.New("SAML SSO authorisation required")
+	ErrRateLimited  = provider.ErrRateLimited
+	ErrNotFound     = provider.ErrNotFound
+	ErrForbidden    = provider.ErrForbidden
+	ErrNotCached    = provider.ErrNotCached
+)
+
+// APIError describes a failed GitHub API request. Kind is one of the Err*
+// sentinels (or nil for other statuses) so callers can use errors.Is, and
+// errors.As gives access to the API message, SSO URL and reset time.
+type APIError struct {
+	Kind             error
+	StatusCode       int
+	URL              string
+	Message          string
+	DocumentationURL string
+	SSOURL           string
+	ResetAt          time.Time
+}
+
+func (e *APIError) Error() string {
+	var msg string
+	switch {
+	case e.Kind == nil:
+		msg = fmt.Sprintf("returned status %d", e.StatusCode)
+	case e.Kind == ErrRateLimited && !e.ResetAt.IsZero():
+		msg = fmt.Sprintf("%s until %s", e.Kind, e.ResetAt.Local().Format("15:04"))
+	default:
+		msg = e.Kind.Error()
+	}
+
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return "GitHub API " + msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// Hint suggests how to fix the failure.
+func (e *APIError) Hint() string {
+	switch e.Kind {
+	case ErrUnauthorized:
+		return "the token is invalid or expired — update the account's token or GITHUB_TOKEN"
+	case ErrSSORequired:
+		return fmt.Sprintf("authorise the token for SAML SSO at %s", e.SSOURL)
+	case ErrRateLimited:
+		if !e.ResetAt.IsZero() {
+			return fmt.Sprintf("wait until %s, raise --max-wait, or use a token for a higher limit", e.ResetAt.Local().Format("15:04"))
+		}
+		return "wait a few minutes, raise --max-wait, or use a token for a higher limit"
+	case ErrNotFound:
+		return "check the username or org name — private repos and orgs also return 404 when the token lacks access"
+	case ErrForbidden:
+		return "the token lacks permission — check its scopes (repo, read:org) and the org's access policy"
+	}
+	return ""
+}
+
+// Code returns a stable machine-readable identifier for the error kind.
+func (e *APIError) Code() string {
+	return ErrorCode(e)
+}
+
+// ErrorCode maps any error to a machine-readable code for --json output.
+func ErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrSSORequired):
+		return "sso_required"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrNotCached):
+		return "not_cached"
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return "api_error"
+	}
+	return "error"
+}
+
+func newAPIError(resp *http.Response, url string) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		URL:        url,
+	}
+
+	var body struct {
+		Message          string `json:"message"`
+		DocumentationURL string `json:"documentation_url"`
+	}
+	if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &body) == nil {
+		apiErr.Message = body.Message
+		apiErr.DocumentationURL = body.DocumentationURL
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		apiErr.Kind = ErrUnauthorized
+	case resp.StatusCode == http.StatusForbidden && parseSSOURL(resp.Header.Get("X-GitHub-SSO")) != "":
+		apiErr.Kind = ErrSSORequired
+		apiErr.SSOURL = parseSSOURL(resp.Header.Get("X-GitHub-SSO"))
+	case isRateLimited(resp):
+		apiErr.Kind = ErrRateLimited
+		apiErr.ResetAt = rateLimitReset(resp.Header)
+	case resp.StatusCode == http.StatusForbidden:
+		apiErr.Kind = ErrForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		apiErr.Kind = ErrNotFound
+	}
+
+	return apiErr
+}
+
+// parseSSOURL extracts the authorisation URL from an X-GitHub-SSO header of
+// the form "required; url=https://github.com/orgs/x/sso?authorization_request=...".
+func parseSSOURL(header string) string {
+	if !strings.HasPrefix(header, "required") {
+		return ""
+	}
+	for _, part := range strings.Split(header, ";") {
+		part = strings.TrimSpace(part)
+		if value, ok := strings.CutPrefix(part, "url="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func rateLimitReset(header http.Header) time.Time {
+	if reset, ok := parseReset(header); ok {
+		return reset
+	}
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestErrors_Unauthorized(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"Bad credentials"}`)
+	})
+	defer server.Close()
+
+	_, err := client.ListRepos("testuser", ListOptions{})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatal("expected *APIError")
+	}
+
+	expectedMessage := "Bad credentials"
+	if apiErr.Message != expectedMessage {
+		t.Errorf("expected message %q, got %q", expectedMessage, apiErr.Message)
+	}
+
+	expectedCode := "unauthorized"
+	if apiErr.Code() != expectedCode {
+		t.Errorf("expected code %q, got %q", expectedCode, apiErr.Code())
+	}
+}
+
+func TestErrors_SSORequired(t *testing.T) {
+	ssoURL := "https://github.com/orgs/myorg/sso?authorization_request=abc"
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-SSO", "required; url="+ssoURL)
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource protected by organization SAML enforcement."}`)
+	})
+	defer server.Close()
+
+	_, err := client.ListRepos("myorg", ListOptions{Mode: ModeOrg})
+	if !errors.Is(err, ErrSSORequired) {
+		t.Fatalf("expected ErrSSORequired, got %v", err)
+	}
+
+	var apiErr *APIError
+	errors.As(err, &apiErr)
+	if apiErr.SSOURL != ssoURL {
+		t.Errorf("expected SSO URL %q, got %q", ssoURL, apiErr.SSOURL)
+	}
+	if hint := apiErr.Hint(); !strings.Contains(hint, ssoURL) {
+		t.Errorf("expected the hint to link the SSO page, got %q", hint)
+	}
+}
+
+func TestErrors_RateLimitedCarriesReset(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "4102444800")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"API rate limit exceeded"}`)
+	})
+	defer server.Close()
+
+	_, err := client.ListRepos("testuser", ListOptions{})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	var apiErr *APIError
+	errors.As(err, &apiErr)
+
+	expectedReset := int64(4102444800)
+	if apiErr.ResetAt.Unix() != expectedReset {
+		t.Errorf("expected reset %d, got %d", expectedReset, apiErr.ResetAt.Unix())
+	}
+}
+
+func TestErrors_ForbiddenIsNotRateLimit(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+	})
+	defer server.Close()
+
+	_, err := client.ListRepos("testuser", ListOptions{})
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("expected no rate limit wording, got %q", err.Error())
+	}
+}
+
+func TestErrors_NotFound(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	})
+	defer server.Close()
+
+	_, err := client.ListRepos("missing-org", ListOptions{Mode: ModeOrg})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	expectedCode := "not_found"
+	if code := ErrorCode(fmt.Errorf("wrapped: %w", err)); code != expectedCode {
+		t.Errorf("expected code %q, got %q", expectedCode, code)
+	}
+}
+
+func TestErrorCode_PlainError(t *testing.T) {
+	expectedCode := "error"
+	if code := ErrorCode(errors.New("boom")); code != expectedCode {
+		t.Errorf("expected code %q, got %q", expectedCode, code)
+	}
+}
+
+func TestParseSSOURL(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"required; url=https://github.com/orgs/x/sso?authorization_request=1", "https://github.com/orgs/x/sso?authorization_request=1"},
+		{"partial-results; organizations=1,2", ""},
+		{"", ""},
+	}
+
+	for _, tc := range tests {
+		if result := parseSSOURL(tc.header); result != tc.expected {
+			t.Errorf("parseSSOURL(%q): expected %q, got %q", tc.header, tc.expected, result)
+		}
+	}
+}
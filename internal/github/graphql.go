@@ -0,0 +1,252 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// reposQuery lists an owner's repos 100 at a time. repositoryOwner resolves
+// both users and organisations, and OWNER affiliation matches the REST
+// /users/{user}/repos and /orgs/{org}/repos defaults.
+const reposQuery = `query($login: String!, $cursor: String) {
+  repositoryOwner(login: $login) {
+    repositories(first: 100, after: $cursor, ownerAffiliations: [OWNER], orderBy: {field: NAME, direction: ASC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        databaseId
+        name
+        nameWithOwner
+        description
+        url
+        sshUrl
+        isFork
+        isArchived
+        hasWikiEnabled
+        isPrivate
+        visibility
+        pushedAt
+        diskUsage
+        primaryLanguage { name }
+        defaultBranchRef { name }
+        repositoryTopics(first: 20) { nodes { topic { name } } }
+        owner { login __typename }
+      }
+    }
+  }
+}`
+
+type graphQLRepo struct {
+	DatabaseID      int64     `json:"databaseId"`
+	Name            string    `json:"name"`
+	NameWithOwner   string    `json:"nameWithOwner"`
+	Description     string    `json:"description"`
+	URL             string    `json:"url"`
+	SSHURL          string    `json:"sshUrl"`
+	IsFork          bool      `json:"isFork"`
+	IsArchived      bool      `json:"isArchived"`
+	HasWikiEnabled  bool      `json:"hasWikiEnabled"`
+	IsPrivate       bool      `json:"isPrivate"`
+	Visibility      string    `json:"visibility"`
+	PushedAt        time.Time `json:"pushedAt"`
+	DiskUsage       int       `json:"diskUsage"`
+	PrimaryLanguage *struct {
+		Name string `json:"name"`
+	} `json:"primaryLanguage"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+	Owner struct {
+		Login    string `json:"login"`
+		Typename string `json:"__typename"`
+	} `json:"owner"`
+}
+
+func (r graphQLRepo) toRepo() Repo {
+	repo := Repo{
+		ID:          r.DatabaseID,
+		Name:        r.Name,
+		FullName:    r.NameWithOwner,
+		Description: r.Description,
+		SSHURL:      r.SSHURL,
+		Fork:        r.IsFork,
+		Archived:    r.IsArchived,
+		HasWiki:     r.HasWikiEnabled,
+		Private:     r.IsPrivate,
+		Visibility:  strings.ToLower(r.Visibility),
+		PushedAt:    r.PushedAt,
+		Size:        r.DiskUsage,
+		Owner:       Owner{Login: r.Owner.Login, Type: r.Owner.Typename},
+	}
+	if r.URL != "" {
+		repo.CloneURL = r.URL + ".git"
+	}
+	if r.PrimaryLanguage != nil {
+		repo.Language = r.PrimaryLanguage.Name
+	}
+	if r.DefaultBranchRef != nil {
+		repo.DefaultBranch = r.DefaultBranchRef.Name
+	}
+	for _, node := range r.RepositoryTopics.Nodes {
+		repo.Topics = append(repo.Topics, node.Topic.Name)
+	}
+	return repo
+}
+
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphQLURL derives the GraphQL endpoint from a REST API URL:
+// https://api.github.com/graphql, or https://host/api/graphql for GHE.
+func graphQLURL(apiURL string) string {
+	if base, ok := strings.CutSuffix(apiURL, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+	return apiURL + "/graphql"
+}
+
+// useGraphQL reports whether a listing can go through GraphQL. The GraphQL
+// API requires a token, and only plain user and org listings map onto it;
+// team, authenticated and typed org listings stay on REST.
+func (c *Client) useGraphQL(mode ListMode, opts ListOptions) bool {
+	return c.token != "" && (mode == ModeUser || mode == ModeOrg) && opts.Type == ""
+}
+
+func (c *Client) listReposGraphQL(owner string) ([]Repo, error) {
+	var allRepos []Repo
+	var cursor *string
+
+	for {
+		var data struct {
+			RepositoryOwner *struct {
+				Repositories struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []graphQLRepo `json:"nodes"`
+				} `json:"repositories"`
+			} `json:"repositoryOwner"`
+		}
+		vars := map[string]any{"login": owner, "cursor": cursor}
+		if err := c.graphQL(reposQuery, vars, &data); err != nil {
+			return nil, err
+		}
+		if data.RepositoryOwner == nil {
+			return nil, &APIError{Kind: ErrNotFound, URL: graphQLURL(c.apiURL), Message: fmt.Sprintf("no user or organisation named %q", owner)}
+		}
+
+		repos := data.RepositoryOwner.Repositories
+		for _, node := range repos.Nodes {
+			allRepos = append(allRepos, node.toRepo())
+		}
+
+		if !repos.PageInfo.HasNextPage || repos.PageInfo.EndCursor == "" {
+			break
+		}
+		next := repos.PageInfo.EndCursor
+		cursor = &next
+	}
+
+	return allRepos, nil
+}
+
+// graphQL runs a query and decodes its data into v. Responses are cached by
+// query and variables; GraphQL has no ETags, so only --offline reads them.
+func (c *Client) graphQL(query string, vars map[string]any, v any) error {
+	endpoint := graphQLURL(c.apiURL)
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return fmt.Errorf("encoding query: %w", err)
+	}
+	cacheKey := endpoint + "\x00" + string(payload)
+
+	body, err := c.postGraphQL(endpoint, cacheKey, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return graphQLErrorFor(endpoint, resp.Errors[0])
+	}
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) postGraphQL(endpoint, cacheKey string, payload []byte) ([]byte, error) {
+	if c.cache != nil && c.cache.mode == CacheOffline {
+		entry, ok := c.cache.get(cacheKey, c.token)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w — run again without --offline", endpoint, ErrNotCached)
+		}
+		return entry.Body, nil
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gitall-cli")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+	c.recordRateLimit(resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, endpoint)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	if c.cache != nil {
+		_ = c.cache.put(cacheKey, c.token, http.Header{}, body)
+	}
+	return body, nil
+}
+
+// graphQLErrorFor maps a GraphQL error onto the same sentinels the REST
+// client uses, so callers see one error model whichever API answered.
+func graphQLErrorFor(endpoint string, gqlErr graphQLError) error {
+	var kind error
+	switch gqlErr.Type {
+	case "NOT_FOUND":
+		kind = ErrNotFound
+	case "FORBIDDEN":
+		kind = ErrForbidden
+	case "RATE_LIMITED":
+		kind = ErrRateLimited
+	default:
+		return fmt.Errorf("GitHub GraphQL API: %s", gqlErr.Message)
+	}
+	return &APIError{Kind: kind, StatusCode: http.StatusOK, URL: endpoint, Message: gqlErr.Message}
+}
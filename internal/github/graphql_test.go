@@ -0,0 +1,176 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+func newGraphQLTestServer(t *testing.T, handler func(req graphQLRequest) string) (*httptest.Server, *Client) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		fmt.Fprint(w, handler(req))
+	}))
+	t.Cleanup(server.Close)
+	return server, NewClient(server.URL, "ghp_testtoken123")
+}
+
+func TestListRepos_UsesGraphQLWithCursorPagination(t *testing.T) {
+	var cursors []any
+	_, client := newGraphQLTestServer(t, func(req graphQLRequest) string {
+		cursors = append(cursors, req.Variables["cursor"])
+		if req.Variables["cursor"] == nil {
+			return `{"data":{"repositoryOwner":{"repositories":{
+				"pageInfo":{"hasNextPage":true,"endCursor":"c1"},
+				"nodes":[{"databaseId":1,"name":"api","nameWithOwner":"myorg/api",
+					"url":"https://github.com/myorg/api","sshUrl":"git@github.com:myorg/api.git",
+					"isArchived":true,"visibility":"PRIVATE","isPrivate":true,"pushedAt":"2026-01-02T03:04:05Z",
+					"primaryLanguage":{"name":"Go"},"defaultBranchRef":{"name":"main"},
+					"repositoryTopics":{"nodes":[{"topic":{"name":"team-payments"}}]},
+					"owner":{"login":"myorg","__typename":"Organization"}}]}}}}`
+		}
+		return `{"data":{"repositoryOwner":{"repositories":{
+			"pageInfo":{"hasNextPage":false,"endCursor":"c2"},
+			"nodes":[{"databaseId":2,"name":"web","isFork":true,"defaultBranchRef":null}]}}}}`
+	})
+
+	repos, err := client.ListRepos("myorg", ListOptions{Mode: ModeOrg})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cursors) != 2 || cursors[0] != nil || cursors[1] != "c1" {
+		t.Errorf("expected cursors [nil c1], got %v", cursors)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %d", len(repos))
+	}
+
+	api := repos[0]
+	if api.ID != 1 || api.FullName != "myorg/api" || api.DefaultBranch != "main" || api.Language != "Go" {
+		t.Errorf("unexpected repo: %+v", api)
+	}
+	if api.CloneURL != "https://github.com/myorg/api.git" || api.SSHURL != "git@github.com:myorg/api.git" {
+		t.Errorf("unexpected URLs: %q %q", api.CloneURL, api.SSHURL)
+	}
+	if !api.Archived || api.RepoVisibility() != "private" || !api.HasTopic("team-payments") {
+		t.Errorf("unexpected flags: %+v", api)
+	}
+	if !api.PushedAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected pushed_at: %v", api.PushedAt)
+	}
+	if !repos[1].Fork || repos[1].DefaultBranch != "" {
+		t.Errorf("unexpected second repo: %+v", repos[1])
+	}
+}
+
+func TestListRepos_GraphQLAppliesFilters(t *testing.T) {
+	_, client := newGraphQLTestServer(t, func(req graphQLRequest) string {
+		return `{"data":{"repositoryOwner":{"repositories":{
+			"pageInfo":{"hasNextPage":false},
+			"nodes":[{"name":"original"},{"name":"forked","isFork":true}]}}}}`
+	})
+
+	repos, err := client.ListRepos("testuser", ListOptions{NoForks: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].Name != "original" {
+		t.Errorf("expected only original, got %+v", repos)
+	}
+}
+
+func TestListRepos_GraphQLUnknownOwnerIsNotFound(t *testing.T) {
+	_, client := newGraphQLTestServer(t, func(req graphQLRequest) string {
+		return `{"data":{"repositoryOwner":null}}`
+	})
+
+	_, err := client.ListRepos("nobody", ListOptions{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListRepos_GraphQLErrorsMapToSentinels(t *testing.T) {
+	_, client := newGraphQLTestServer(t, func(req graphQLRequest) string {
+		return `{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`
+	})
+
+	_, err := client.ListRepos("myorg", ListOptions{Mode: ModeOrg})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestListRepos_TeamModeStaysOnREST(t *testing.T) {
+	var receivedMethod string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedMethod = r.Method
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "ghp_testtoken123")
+	if _, err := client.ListRepos("myorg", ListOptions{Mode: ModeTeam, Team: "platform"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receivedMethod != "GET" {
+		t.Errorf("expected REST GET, got %s", receivedMethod)
+	}
+}
+
+func TestGraphQL_RetryResendsBody(t *testing.T) {
+	requestCount := 0
+	client, _ := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+			t.Errorf("attempt %d: missing request body", requestCount)
+		}
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"repositoryOwner":{"repositories":{"pageInfo":{"hasNextPage":false},"nodes":[{"name":"repo1"}]}}}}`)
+	})
+	client.token = "ghp_testtoken123"
+
+	repos, err := client.ListRepos("testuser", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requestCount != 2 || len(repos) != 1 {
+		t.Errorf("expected 2 requests and 1 repo, got %d and %d", requestCount, len(repos))
+	}
+}
+
+func TestGraphQLURL(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com":         "https://api.github.com/graphql",
+		"https://ghe.example.com/api/v3": "https://ghe.example.com/api/graphql",
+		"http://127.0.0.1:8080":          "http://127.0.0.1:8080/graphql",
+	}
+	for input, expected := range tests {
+		if got := graphQLURL(input); got != expected {
+			t.Errorf("graphQLURL(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
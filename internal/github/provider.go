@@ -0,0 +1,112 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func init() {
+	provider.Register("github", newAPIProvider)
+}
+
+// apiProvider puts the GitHub API client behind provider.Provider. It serves
+// both github.com and GitHub Enterprise Server, depending on the API URL.
+// The client is built on first use, so commands that only need the host do
+// not resolve credentials.
+type apiProvider struct {
+	apiURL  string
+	account provider.Account
+	opts    provider.Options
+	mode    ListMode
+}
+
+func newAPIProvider(account provider.Account, opts provider.Options) (provider.Provider, error) {
+	mode, err := ParseListMode(account.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if account.Team != "" {
+		mode = ModeTeam
+	}
+
+	return &apiProvider{
+		apiURL:  NormalizeAPIURL(account.APIURL),
+		account: account,
+		opts:    opts,
+		mode:    mode,
+	}, nil
+}
+
+func (p *apiProvider) client() *Client {
+	token := p.account.Token
+	if p.opts.Credentials != nil {
+		token = p.opts.Credentials(p.account.Token, p.Host())
+	}
+	client := NewClient(p.apiURL, token)
+	client.Configure(p.opts)
+	return client
+}
+
+// Configure applies the CLI-wide cache and retry settings.
+func (c *Client) Configure(opts provider.Options) {
+	mode := CacheRevalidate
+	switch {
+	case opts.Offline:
+		mode = CacheOffline
+	case opts.Refresh:
+		mode = CacheRefresh
+	}
+	c.SetCache(NewCache(DefaultCacheDir(), mode))
+
+	policy := DefaultRetryPolicy()
+	policy.MaxRetries = opts.Retries
+	if opts.MaxWait > 0 {
+		policy.MaxWait = opts.MaxWait
+	}
+	c.SetRetryPolicy(policy)
+}
+
+func (p *apiProvider) Name() string {
+	return "github"
+}
+
+func (p *apiProvider) Host() string {
+	return WebHost(p.apiURL)
+}
+
+func (p *apiProvider) ListRepos(filter provider.Filter) ([]Repo, error) {
+	return p.client().ListRepos(p.account.Owner, ListOptions{
+		NoForks:     filter.NoForks,
+		NoArchived:  filter.NoArchived,
+		Filter:      filter.Pattern,
+		Mode:        p.mode,
+		Team:        p.account.Team,
+		Type:        p.account.Params["type"],
+		Affiliation: p.account.Params["affiliation"],
+		Visibility:  filter.Visibility,
+		Topics:      filter.Topics,
+		Language:    filter.Language,
+		PushedAfter: filter.PushedAfter,
+		MaxSize:     filter.MaxSize,
+	})
+}
+
+func (p *apiProvider) CloneURL(repo Repo, protocol string) string {
+	return CloneURLForHost(repo, protocol, p.account.Owner, p.Host())
+}
+
+func (p *apiProvider) WikiURL(repo Repo, protocol string) string {
+	return WikiURL(repo, protocol, p.account.Owner, p.Host())
+}
+
+// ParseRemote accepts owner/name remotes on the provider's host. GitHub has
+// no nested groups, so deeper paths belong to some other forge.
+func (p *apiProvider) ParseRemote(remoteURL string) (git.Remote, bool) {
+	remote, ok := git.ParseRemoteURL(remoteURL)
+	if !ok || remote.Host != p.Host() || strings.Contains(remote.Owner, "/") {
+		return git.Remote{}, false
+	}
+	return remote, true
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func TestProvider_ListsReposThroughRegistry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var receivedPath, receivedType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedType = r.URL.Query().Get("type")
+		fmt.Fprint(w, `[{"name":"api","clone_url":"https://ghe.example.com/myorg/api.git"},{"name":"old","archived":true}]`)
+	}))
+	defer server.Close()
+
+	p, err := provider.New("", provider.Account{
+		Owner:  "myorg",
+		APIURL: server.URL + "/api/v3",
+		Mode:   "org",
+		Params: map[string]string{"type": "sources"},
+	}, provider.Options{Refresh: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repos, err := p.ListRepos(provider.Filter{NoArchived: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedPath != "/api/v3/orgs/myorg/repos" || receivedType != "sources" {
+		t.Errorf("unexpected request %q type=%q", receivedPath, receivedType)
+	}
+	if len(repos) != 1 || repos[0].Name != "api" {
+		t.Fatalf("expected only api, got %+v", repos)
+	}
+	if got := p.CloneURL(repos[0], "https"); got != "https://ghe.example.com/myorg/api.git" {
+		t.Errorf("unexpected clone URL %q", got)
+	}
+}
+
+func TestProvider_ParseRemoteMatchesHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	p, err := provider.New("github", provider.Account{Owner: "me", APIURL: "https://ghe.example.com"}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remote, ok := p.ParseRemote("git@ghe.example.com:team/tool.git")
+	if !ok || remote.Owner != "team" || remote.Name != "tool" {
+		t.Errorf("expected team/tool, got %+v (ok=%v)", remote, ok)
+	}
+	if _, ok := p.ParseRemote("git@github.com:team/tool.git"); ok {
+		t.Error("expected a github.com remote not to match an Enterprise provider")
+	}
+	if _, ok := p.ParseRemote("git@ghe.example.com:group/sub/tool.git"); ok {
+		t.Error("expected a nested group remote not to match")
+	}
+	if got := p.CloneURL(Repo{Name: "tool"}, "ssh"); got != "git@ghe.example.com:me/tool.git" {
+		t.Errorf("unexpected clone URL %q", got)
+	}
+}
+
+func TestProvider_AuthenticatedListingKeepsRepoOwners(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/users/me", "/api/v3/user":
+			fmt.Fprint(w, `{"login":"me","type":"User"}`)
+		case "/api/v3/user/repos":
+			fmt.Fprint(w, `[{"name":"dotfiles","owner":{"login":"me","type":"User"}},{"name":"foo","owner":{"login":"acme","type":"Organization"}}]`)
+		}
+	}))
+	defer server.Close()
+
+	p, err := provider.New("github", provider.Account{Owner: "me", APIURL: server.URL, Token: "ghp_testtoken123"}, provider.Options{Refresh: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repos, err := p.ListRepos(provider.Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %+v", repos)
+	}
+
+	host := p.Host()
+	if got := p.CloneURL(repos[0], "ssh"); got != "git@"+host+":me/dotfiles.git" {
+		t.Errorf("unexpected clone URL for the user's repo %q", got)
+	}
+	if got := p.CloneURL(repos[1], "ssh"); got != "git@"+host+":acme/foo.git" {
+		t.Errorf("expected the org repo to clone from its owner, got %q", got)
+	}
+	if owner := repos[1].ListedOwner("me"); owner != "acme" {
+		t.Errorf("expected acme as the org repo's owner, got %q", owner)
+	}
+}
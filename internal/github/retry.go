@@ -0,0 +1,208 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secondaryLimitWait is how long GitHub asks clients to back off after a
+// secondary rate limit response that carries no Retry-After header.
+const secondaryLimitWait = time.Minute
+
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt
+	MaxWait    time.Duration // total time spent waiting across all retries
+	BaseDelay  time.Duration // first backoff delay for server and network errors
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		MaxWait:    2 * time.Minute,
+		BaseDelay:  time.Second,
+	}
+}
+
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+func (r RateLimit) String() string {
+	return fmt.Sprintf("%d/%d requests remaining, resets at %s", r.Remaining, r.Limit, r.Reset.Local().Format("15:04"))
+}
+
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// RateLimit fetches the core REST quota for the client's token.
+func (c *Client) RateLimit() (*RateLimit, error) {
+	var resp struct {
+		Resources struct {
+			Core struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	if err := c.getJSON(c.apiURL+"/rate_limit", &resp); err != nil {
+		return nil, err
+	}
+
+	core := resp.Resources.Core
+	return &RateLimit{
+		Limit:     core.Limit,
+		Remaining: core.Remaining,
+		Reset:     time.Unix(core.Reset, 0),
+	}, nil
+}
+
+// do sends req, waiting and retrying on rate limits, 5xx responses and
+// network errors until the retry policy's attempt or time budget runs out.
+// The returned response body is always fully buffered.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	var waited time.Duration
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewinding request body: %w", err)
+			}
+			req.Body = body
+		}
+		resp, err := c.httpClient.Do(req)
+		if err == nil {
+			err = bufferBody(resp)
+		}
+
+		delay, retryable := c.retryDelay(resp, err, attempt)
+		if !retryable || attempt >= c.retry.MaxRetries || waited+delay > c.retry.MaxWait {
+			return resp, err
+		}
+
+		c.sleep(delay)
+		waited += delay
+	}
+}
+
+func bufferBody(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+func (c *Client) retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		return c.backoff(attempt), true
+	}
+
+	switch {
+	case resp.StatusCode >= 500:
+		return c.backoff(attempt), true
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden:
+		return c.rateLimitDelay(resp, attempt)
+	default:
+		return 0, false
+	}
+}
+
+func (c *Client) rateLimitDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, ok := parseReset(resp.Header); ok {
+			delay := reset.Sub(c.now()) + time.Second
+			if delay < 0 {
+				delay = 0
+			}
+			return delay, true
+		}
+	}
+
+	if isSecondaryRateLimit(resp) {
+		return secondaryLimitWait, true
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return c.backoff(attempt), true
+	}
+	return 0, false
+}
+
+// backoff returns an exponentially growing delay with full jitter in the
+// upper half, so concurrent clients do not retry in lockstep.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retry.BaseDelay << attempt
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+func parseReset(header http.Header) (time.Time, bool) {
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(reset, 0), true
+}
+
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden &&
+		(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != "" || isSecondaryRateLimit(resp))
+}
+
+func isSecondaryRateLimit(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
+
+func (c *Client) recordRateLimit(header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, _ := parseReset(header)
+
+	c.mu.Lock()
+	c.lastRateLimit = &RateLimit{Limit: limit, Remaining: remaining, Reset: reset}
+	c.mu.Unlock()
+}
+
+// LastRateLimit returns the quota reported by the most recent API response,
+// or nil if no response has carried rate limit headers yet.
+func (c *Client) LastRateLimit() *RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastRateLimit
+}
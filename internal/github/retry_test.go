@@ -0,0 +1,229 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newRetryTestServer(t *testing.T, handler http.HandlerFunc) (*Client, *[]time.Duration) {
+	t.Helper()
+	server, client := newTestServer(handler)
+	t.Cleanup(server.Close)
+
+	var sleeps []time.Duration
+	client.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	client.now = func() time.Time { return time.Unix(1000, 0) }
+	return client, &sleeps
+}
+
+func TestRetry_WaitsForRateLimitReset(t *testing.T) {
+	requestCount := 0
+	client, sleeps := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1030")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `[{"name":"repo1"}]`)
+	})
+
+	repos, err := client.ListRepos("testuser", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedCount := 1
+	if len(repos) != expectedCount {
+		t.Errorf("expected %d repo, got %d", expectedCount, len(repos))
+	}
+
+	expectedSleeps := []time.Duration{31 * time.Second}
+	if len(*sleeps) != 1 || (*sleeps)[0] != expectedSleeps[0] {
+		t.Errorf("expected sleeps %v, got %v", expectedSleeps, *sleeps)
+	}
+}
+
+func TestRetry_GivesUpWhenResetExceedsBudget(t *testing.T) {
+	client, sleeps := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(1000+3600))
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err == nil {
+		t.Fatal("expected rate limit error, got nil")
+	}
+
+	if len(*sleeps) != 0 {
+		t.Errorf("expected no sleeps, got %v", *sleeps)
+	}
+}
+
+func TestRetry_HonoursRetryAfter(t *testing.T) {
+	requestCount := 0
+	client, sleeps := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedSleep := 5 * time.Second
+	if len(*sleeps) != 1 || (*sleeps)[0] != expectedSleep {
+		t.Errorf("expected one sleep of %v, got %v", expectedSleep, *sleeps)
+	}
+}
+
+func TestRetry_BacksOffOnSecondaryRateLimit(t *testing.T) {
+	requestCount := 0
+	client, sleeps := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit."}`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(*sleeps) != 1 || (*sleeps)[0] != secondaryLimitWait {
+		t.Errorf("expected one sleep of %v, got %v", secondaryLimitWait, *sleeps)
+	}
+}
+
+func TestRetry_RetriesServerErrorsWithBackoff(t *testing.T) {
+	requestCount := 0
+	client, sleeps := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedSleeps := 2
+	if len(*sleeps) != expectedSleeps {
+		t.Fatalf("expected %d sleeps, got %d", expectedSleeps, len(*sleeps))
+	}
+
+	base := DefaultRetryPolicy().BaseDelay
+	for i, d := range *sleeps {
+		max := base << i
+		if d < max/2 || d > max {
+			t.Errorf("sleep %d: expected between %v and %v, got %v", i, max/2, max, d)
+		}
+	}
+}
+
+func TestRetry_StopsAfterMaxRetries(t *testing.T) {
+	requestCount := 0
+	client, _ := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 2, MaxWait: time.Hour, BaseDelay: time.Millisecond})
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	expectedRequests := 3
+	if requestCount != expectedRequests {
+		t.Errorf("expected %d requests, got %d", expectedRequests, requestCount)
+	}
+}
+
+func TestRetry_DoesNotRetryPlainForbidden(t *testing.T) {
+	requestCount := 0
+	client, _ := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+	})
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	expectedRequests := 1
+	if requestCount != expectedRequests {
+		t.Errorf("expected %d request, got %d", expectedRequests, requestCount)
+	}
+}
+
+func TestRateLimit_ParsesCoreQuota(t *testing.T) {
+	client, _ := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"resources":{"core":{"limit":5000,"remaining":4200,"reset":2000}}}`)
+	})
+
+	limit, err := client.RateLimit()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedRemaining := 4200
+	if limit.Remaining != expectedRemaining {
+		t.Errorf("expected %d remaining, got %d", expectedRemaining, limit.Remaining)
+	}
+
+	expectedReset := time.Unix(2000, 0)
+	if !limit.Reset.Equal(expectedReset) {
+		t.Errorf("expected reset %v, got %v", expectedReset, limit.Reset)
+	}
+}
+
+func TestLastRateLimit_RecordsResponseHeaders(t *testing.T) {
+	client, _ := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "59")
+		w.Header().Set("X-RateLimit-Reset", "2000")
+		fmt.Fprint(w, `[]`)
+	})
+
+	client.ListRepos("testuser", ListOptions{})
+
+	limit := client.LastRateLimit()
+	if limit == nil {
+		t.Fatal("expected rate limit to be recorded")
+	}
+
+	expectedRemaining := 59
+	if limit.Remaining != expectedRemaining {
+		t.Errorf("expected %d remaining, got %d", expectedRemaining, limit.Remaining)
+	}
+}
+
+func TestRetry_RetriesNetworkErrors(t *testing.T) {
+	client, sleeps := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	client.apiURL = "http://127.0.0.1:1"
+
+	if _, err := client.ListRepos("testuser", ListOptions{}); err == nil {
+		t.Fatal("expected network error, got nil")
+	}
+
+	expectedSleeps := DefaultRetryPolicy().MaxRetries
+	if len(*sleeps) != expectedSleeps {
+		t.Errorf("expected %d sleeps, got %d", expectedSleeps, len(*sleeps))
+	}
+}
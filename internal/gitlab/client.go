@@ -0,0 +1,321 @@
+// Package gitlab lists projects from gitlab.com or a self-hosted GitLab
+// through the REST API v4.
+package gitlab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+const (
+	defaultAPIURL = "https://gitlab.com/api/v4"
+	defaultHost   = "gitlab.com"
+)
+
+type ListMode string
+
+const (
+	ModeAuto  ListMode = "auto"  // a group if one exists, else a user
+	ModeGroup ListMode = "group" // a group and all of its subgroups
+	ModeUser  ListMode = "user"  // a personal namespace
+)
+
+func ParseListMode(s string) (ListMode, error) {
+	switch mode := ListMode(strings.ToLower(s)); mode {
+	case "":
+		return ModeAuto, nil
+	case ModeAuto, ModeGroup, ModeUser:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid GitLab listing mode %q (must be auto, group or user)", s)
+}
+
+type ListOptions struct {
+	Mode       ListMode
+	NoArchived bool // sent as archived=false so the server drops them
+}
+
+type Client struct {
+	apiURL     string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(apiURL, token string) *Client {
+	return &Client{
+		apiURL:     NormalizeAPIURL(apiURL),
+		token:      token,
+		httpClient: &http.Client{},
+	}
+}
+
+// NormalizeAPIURL turns a GitLab base URL such as https://gitlab.example.com
+// into its REST endpoint https://gitlab.example.com/api/v4. URLs that already
+// carry a path are left unchanged.
+func NormalizeAPIURL(apiURL string) string {
+	if apiURL == "" {
+		return defaultAPIURL
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host == "" || parsed.Path != "" {
+		return apiURL
+	}
+	return apiURL + "/api/v4"
+}
+
+// WebHost returns the host that serves git and web traffic for an API URL.
+func WebHost(apiURL string) string {
+	if apiURL == "" {
+		return defaultHost
+	}
+	parsed, err := url.Parse(apiURL)
+	if err != nil || parsed.Host == "" {
+		return defaultHost
+	}
+	return parsed.Host
+}
+
+func (c *Client) Host() string {
+	return WebHost(c.apiURL)
+}
+
+// project is the subset of GitLab's project representation gitall uses.
+type project struct {
+	ID                int64     `json:"id"`
+	Path              string    `json:"path"`
+	PathWithNamespace string    `json:"path_with_namespace"`
+	Description       string    `json:"description"`
+	DefaultBranch     string    `json:"default_branch"`
+	SSHURL            string    `json:"ssh_url_to_repo"`
+	HTTPURL           string    `json:"http_url_to_repo"`
+	Topics            []string  `json:"topics"`
+	TagList           []string  `json:"tag_list"` // topics before GitLab 14.0
+	Visibility        string    `json:"visibility"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+	Archived          bool      `json:"archived"`
+	WikiEnabled       bool      `json:"wiki_enabled"`
+	ForkedFrom        *struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"forked_from_project"`
+	Namespace struct {
+		FullPath string `json:"full_path"`
+		Kind     string `json:"kind"` // "group" or "user"
+	} `json:"namespace"`
+}
+
+// toRepo converts a project listed under owner. The part of the project's
+// namespace below owner becomes the repo's Namespace.
+func (p project) toRepo(owner string) provider.Repo {
+	topics := p.Topics
+	if len(topics) == 0 {
+		topics = p.TagList
+	}
+
+	ownerType := "User"
+	if p.Namespace.Kind == "group" {
+		ownerType = "Group"
+	}
+
+	repo := provider.Repo{
+		ID:            p.ID,
+		Name:          p.Path,
+		FullName:      p.PathWithNamespace,
+		Description:   p.Description,
+		CloneURL:      p.HTTPURL,
+		SSHURL:        p.SSHURL,
+		DefaultBranch: p.DefaultBranch,
+		Topics:        topics,
+		Visibility:    p.Visibility,
+		Private:       p.Visibility == "private",
+		PushedAt:      p.LastActivityAt,
+		Fork:          p.ForkedFrom != nil,
+		Archived:      p.Archived,
+		HasWiki:       p.WikiEnabled,
+		Owner:         provider.Owner{Login: p.Namespace.FullPath, Type: ownerType},
+	}
+	if rest, ok := cutPrefixFold(p.Namespace.FullPath, owner+"/"); ok {
+		repo.Namespace = rest
+	}
+	return repo
+}
+
+// ListProjects lists the projects of a group, including every subgroup, or
+// of a user's personal namespace. Groups may be given by their full path,
+// e.g. "acme/platform".
+func (c *Client) ListProjects(owner string, opts ListOptions) ([]provider.Repo, error) {
+	query := url.Values{}
+	query.Set("per_page", "100")
+	query.Set("order_by", "id")
+	query.Set("sort", "asc")
+	if opts.NoArchived {
+		query.Set("archived", "false")
+	}
+
+	var projects []project
+	var err error
+	switch opts.Mode {
+	case ModeUser:
+		projects, err = c.listUserProjects(owner, query)
+	case ModeGroup:
+		projects, err = c.listGroupProjects(owner, query)
+	default:
+		projects, err = c.listGroupProjects(owner, query)
+		if errors.Is(err, provider.ErrNotFound) {
+			projects, err = c.listUserProjects(owner, query)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]provider.Repo, len(projects))
+	for i, p := range projects {
+		repos[i] = p.toRepo(owner)
+	}
+	return repos, nil
+}
+
+func (c *Client) listGroupProjects(group string, query url.Values) ([]project, error) {
+	query = maps.Clone(query)
+	query.Set("include_subgroups", "true")
+	query.Set("with_shared", "false")
+	return c.listAll(fmt.Sprintf("%s/groups/%s/projects?%s", c.apiURL, url.PathEscape(group), query.Encode()))
+}
+
+func (c *Client) listUserProjects(user string, query url.Values) ([]project, error) {
+	return c.listAll(fmt.Sprintf("%s/users/%s/projects?%s", c.apiURL, url.PathEscape(user), query.Encode()))
+}
+
+// listAll follows pagination until the last page. Keyset-paginated
+// responses carry the next page in a Link header; offset-paginated ones
+// carry its number in X-Next-Page.
+func (c *Client) listAll(pageURL string) ([]project, error) {
+	var all []project
+	for pageURL != "" {
+		body, header, err := c.get(pageURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var projects []project
+		if err := json.Unmarshal(body, &projects); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		all = append(all, projects...)
+
+		pageURL = nextPageURL(pageURL, header)
+	}
+	return all, nil
+}
+
+func nextPageURL(current string, header http.Header) string {
+	if next := provider.NextLink(header.Get("Link")); next != "" {
+		return next
+	}
+
+	page := header.Get("X-Next-Page")
+	if page == "" {
+		return ""
+	}
+	parsed, err := url.Parse(current)
+	if err != nil {
+		return ""
+	}
+	query := parsed.Query()
+	query.Set("page", page)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func (c *Client) get(url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "gitall-cli")
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("calling GitLab API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, newAPIError(resp, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response: %w", err)
+	}
+	return body, resp.Header, nil
+}
+
+func newAPIError(resp *http.Response, url string) *provider.APIError {
+	apiErr := &provider.APIError{
+		Forge:      "GitLab",
+		Kind:       provider.KindForStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		URL:        url,
+		TokenVars:  "GITLAB_TOKEN",
+		Scopes:     "read_api",
+	}
+
+	// GitLab reports errors as {"message": ...} or {"error": ...}, and the
+	// message is sometimes an object of field errors.
+	var body struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &body) == nil {
+		switch msg := body.Message.(type) {
+		case string:
+			apiErr.Message = msg
+		case nil:
+			apiErr.Message = body.Error
+		default:
+			if encoded, err := json.Marshal(msg); err == nil {
+				apiErr.Message = string(encoded)
+			}
+		}
+	}
+
+	if apiErr.Kind == provider.ErrRateLimited {
+		if reset, err := parseUnix(resp.Header.Get("RateLimit-Reset")); err == nil {
+			apiErr.ResetAt = reset
+		}
+	}
+	return apiErr
+}
+
+func parseUnix(s string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return "", false
+	}
+	return s[len(prefix):], true
+}
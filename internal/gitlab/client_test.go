@@ -0,0 +1,205 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func newTestServer(handler http.HandlerFunc) (*httptest.Server, *Client) {
+	server := httptest.NewServer(handler)
+	client := NewClient(server.URL+"/api/v4", "")
+	return server, client
+}
+
+func TestListProjects_GroupIncludesSubgroups(t *testing.T) {
+	var receivedPath, receivedSubgroups, receivedArchived string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.EscapedPath()
+		receivedSubgroups = r.URL.Query().Get("include_subgroups")
+		receivedArchived = r.URL.Query().Get("archived")
+		fmt.Fprint(w, `[
+			{"id":1,"path":"api","path_with_namespace":"acme/platform/backend/api","namespace":{"full_path":"acme/platform/backend","kind":"group"},"forked_from_project":{"path_with_namespace":"other/api"}},
+			{"id":2,"path":"site","path_with_namespace":"acme/site","namespace":{"full_path":"acme","kind":"group"},"tag_list":["web"]}
+		]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListProjects("acme", ListOptions{Mode: ModeGroup, NoArchived: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedPath != "/api/v4/groups/acme/projects" || receivedSubgroups != "true" || receivedArchived != "false" {
+		t.Errorf("unexpected request %q include_subgroups=%q archived=%q", receivedPath, receivedSubgroups, receivedArchived)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %d", len(repos))
+	}
+	if repos[0].Name != "api" || repos[0].Namespace != "platform/backend" || !repos[0].Fork {
+		t.Errorf("unexpected nested repo %+v", repos[0])
+	}
+	if repos[1].Namespace != "" || !repos[1].HasTopic("web") {
+		t.Errorf("unexpected top-level repo %+v", repos[1])
+	}
+}
+
+func TestListProjects_EscapesNestedGroupPath(t *testing.T) {
+	var receivedPath string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.EscapedPath()
+		fmt.Fprint(w, `[{"path":"api","namespace":{"full_path":"acme/platform/backend"}}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListProjects("acme/platform", ListOptions{Mode: ModeGroup})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedPath != "/api/v4/groups/acme%2Fplatform/projects" {
+		t.Errorf("unexpected path %q", receivedPath)
+	}
+	if repos[0].Namespace != "backend" {
+		t.Errorf("expected namespace backend, got %q", repos[0].Namespace)
+	}
+}
+
+func TestListProjects_AutoFallsBackToUser(t *testing.T) {
+	var paths []string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/v4/groups/jane/projects" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 Group Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, `[{"path":"dotfiles","namespace":{"full_path":"jane","kind":"user"}}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListProjects("jane", ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(paths) != 2 || paths[1] != "/api/v4/users/jane/projects" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+	if len(repos) != 1 || repos[0].Owner.Type != "User" {
+		t.Errorf("unexpected repos %+v", repos)
+	}
+}
+
+func TestListProjects_FollowsNextPageHeader(t *testing.T) {
+	var pages []string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		if page == "" {
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"path":"one"}]`)
+			return
+		}
+		w.Header().Set("X-Next-Page", "")
+		fmt.Fprint(w, `[{"path":"two"}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListProjects("jane", ListOptions{Mode: ModeUser})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repos) != 2 || len(pages) != 2 || pages[1] != "2" {
+		t.Errorf("expected two pages, got repos %+v pages %v", repos, pages)
+	}
+}
+
+func TestListProjects_FollowsKeysetLink(t *testing.T) {
+	requestCount := 0
+	var secondQuery string
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v4/users/jane/projects?id_after=10&pagination=keyset>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"path":"one"}]`)
+			return
+		}
+		secondQuery = r.URL.RawQuery
+		fmt.Fprint(w, `[{"path":"two"}]`)
+	})
+	defer server.Close()
+
+	repos, err := client.ListProjects("jane", ListOptions{Mode: ModeUser})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repos) != 2 || secondQuery != "id_after=10&pagination=keyset" {
+		t.Errorf("expected the Link URL to be followed, got repos %+v query %q", repos, secondQuery)
+	}
+}
+
+func TestListProjects_SendsPrivateToken(t *testing.T) {
+	var receivedToken, receivedAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedToken = r.Header.Get("PRIVATE-TOKEN")
+		receivedAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/api/v4", "glpat-secret")
+	if _, err := client.ListProjects("jane", ListOptions{Mode: ModeUser}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedToken != "glpat-secret" || receivedAuth != "" {
+		t.Errorf("expected PRIVATE-TOKEN only, got token %q auth %q", receivedToken, receivedAuth)
+	}
+}
+
+func TestListProjects_MapsErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		kind   error
+	}{
+		{http.StatusUnauthorized, provider.ErrUnauthorized},
+		{http.StatusForbidden, provider.ErrForbidden},
+		{http.StatusTooManyRequests, provider.ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, `{"message":"nope"}`)
+		})
+
+		_, err := client.ListProjects("acme", ListOptions{Mode: ModeGroup})
+		server.Close()
+
+		var apiErr *provider.APIError
+		if !errors.Is(err, tt.kind) || !errors.As(err, &apiErr) || apiErr.Message != "nope" {
+			t.Errorf("status %d: expected %v with message, got %v", tt.status, tt.kind, err)
+		}
+	}
+}
+
+func TestNormalizeAPIURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                  "https://gitlab.com/api/v4",
+		"https://gitlab.example.com":        "https://gitlab.example.com/api/v4",
+		"https://gitlab.example.com/":       "https://gitlab.example.com/api/v4",
+		"https://gitlab.example.com/api/v4": "https://gitlab.example.com/api/v4",
+	}
+	for input, expected := range tests {
+		if got := NormalizeAPIURL(input); got != expected {
+			t.Errorf("NormalizeAPIURL(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package gitlab
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func init() {
+	provider.Register("gitlab", newAPIProvider)
+}
+
+// apiProvider puts the GitLab client behind provider.Provider. Responses are
+// not cached, so it cannot serve --offline.
+type apiProvider struct {
+	apiURL  string
+	account provider.Account
+	opts    provider.Options
+	mode    ListMode
+}
+
+func newAPIProvider(account provider.Account, opts provider.Options) (provider.Provider, error) {
+	mode, err := ParseListMode(account.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if account.Team != "" {
+		return nil, fmt.Errorf("GitLab accounts do not support team listing; use the subgroup path as the username instead")
+	}
+
+	return &apiProvider{
+		apiURL:  NormalizeAPIURL(account.APIURL),
+		account: account,
+		opts:    opts,
+		mode:    mode,
+	}, nil
+}
+
+// token returns the account's token, falling back to GITLAB_TOKEN. The
+// shared credential chain is GitHub's and is not consulted.
+func (p *apiProvider) token() string {
+	token, _ := p.Credential()
+	return token
+}
+
+func (p *apiProvider) Credential() (string, string) {
+	if p.account.Token != "" {
+		return p.account.Token, "config"
+	}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		return token, "GITLAB_TOKEN"
+	}
+	return "", ""
+}
+
+func (p *apiProvider) Name() string {
+	return "gitlab"
+}
+
+func (p *apiProvider) Host() string {
+	return WebHost(p.apiURL)
+}
+
+func (p *apiProvider) NestedGroups() bool {
+	return true
+}
+
+func (p *apiProvider) ListRepos(filter provider.Filter) ([]provider.Repo, error) {
+	if p.opts.Offline {
+		return nil, fmt.Errorf("%s: %w — GitLab responses are not cached, run again without --offline", p.apiURL, provider.ErrNotCached)
+	}
+
+	repos, err := NewClient(p.apiURL, p.token()).ListProjects(p.account.Owner, ListOptions{
+		Mode:       p.mode,
+		NoArchived: filter.NoArchived,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return provider.FilterRepos(repos, filter), nil
+}
+
+func (p *apiProvider) CloneURL(repo provider.Repo, protocol string) string {
+	fullName := repo.FullName
+	if fullName == "" {
+		fullName = p.account.Owner + "/" + repo.Name
+	}
+
+	switch protocol {
+	case "https":
+		if repo.CloneURL != "" {
+			return repo.CloneURL
+		}
+		return "https://" + p.Host() + "/" + fullName + ".git"
+	default:
+		if repo.SSHURL != "" {
+			return repo.SSHURL
+		}
+		return "git@" + p.Host() + ":" + fullName + ".git"
+	}
+}
+
+// WikiURL returns the clone URL of a project's wiki, which GitLab serves as
+// a separate repo next to the main one.
+func (p *apiProvider) WikiURL(repo provider.Repo, protocol string) string {
+	return strings.TrimSuffix(p.CloneURL(repo, protocol), ".git") + ".wiki.git"
+}
+
+// ParseRemote accepts remotes on the provider's host. The owner keeps the
+// full group path, e.g. "acme/platform" for acme/platform/api.
+func (p *apiProvider) ParseRemote(remoteURL string) (git.Remote, bool) {
+	remote, ok := git.ParseRemoteURL(remoteURL)
+	if !ok || !strings.EqualFold(remote.Host, p.Host()) {
+		return git.Remote{}, false
+	}
+	return remote, true
+}
@@ -0,0 +1,65 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func TestProvider_ListsReposThroughRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"path":"api","path_with_namespace":"acme/platform/api","ssh_url_to_repo":"git@gitlab.example.com:acme/platform/api.git","namespace":{"full_path":"acme/platform"}},
+			{"path":"fork","forked_from_project":{}}
+		]`)
+	}))
+	defer server.Close()
+
+	p, err := provider.New("gitlab", provider.Account{Owner: "acme", APIURL: server.URL}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repos, err := p.ListRepos(provider.Filter{NoForks: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repos) != 1 || repos[0].Namespace != "platform" {
+		t.Fatalf("expected only platform/api, got %+v", repos)
+	}
+	if got := p.CloneURL(repos[0], "ssh"); got != "git@gitlab.example.com:acme/platform/api.git" {
+		t.Errorf("unexpected clone URL %q", got)
+	}
+}
+
+func TestProvider_ParseRemoteKeepsGroupPath(t *testing.T) {
+	p, err := provider.New("gitlab", provider.Account{Owner: "acme", APIURL: "https://gitlab.example.com"}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remote, ok := p.ParseRemote("git@gitlab.example.com:acme/platform/backend/api.git")
+	if !ok || remote.Owner != "acme/platform/backend" || remote.Name != "api" {
+		t.Errorf("expected acme/platform/backend/api, got %+v (ok=%v)", remote, ok)
+	}
+	if _, ok := p.ParseRemote("git@gitlab.com:acme/api.git"); ok {
+		t.Error("expected a gitlab.com remote not to match a self-hosted provider")
+	}
+	if got := p.CloneURL(provider.Repo{Name: "tool"}, "https"); got != "https://gitlab.example.com/acme/tool.git" {
+		t.Errorf("unexpected clone URL %q", got)
+	}
+}
+
+func TestProvider_RejectsOffline(t *testing.T) {
+	p, err := provider.New("gitlab", provider.Account{Owner: "acme"}, provider.Options{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := p.ListRepos(provider.Filter{}); err == nil {
+		t.Fatal("expected an error in offline mode, got nil")
+	}
+}
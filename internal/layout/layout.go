@@ -0,0 +1,183 @@
+// Package layout maps repos to checkout directories using path templates
+// such as "{dir}/{name}" or "{root}/{host}/{owner}/{name}".
+package layout
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultTemplate clones each repo into its account dir.
+const DefaultTemplate = "{dir}/{name}"
+
+// NoTopic is the {topic} value for repos without topics.
+const NoTopic = "untagged"
+
+var placeholder = regexp.MustCompile(`\{([a-z]+)\}`)
+
+var knownPlaceholders = map[string]bool{
+	"dir":   true,
+	"root":  true,
+	"host":  true,
+	"owner": true,
+	"name":  true,
+	"topic": true,
+}
+
+// Layout decides where a repo is checked out. Lowercase applies to the
+// host, owner, name and topic values, never to {dir} or {root}.
+type Layout struct {
+	Template  string `yaml:"template,omitempty"`
+	Root      string `yaml:"root,omitempty"`
+	Lowercase bool   `yaml:"lowercase,omitempty"`
+}
+
+// Vars are the values substituted into a template for one repo.
+type Vars struct {
+	Dir    string // the account dir
+	Host   string
+	Owner  string
+	Name   string
+	Topics []string
+	// Namespace is the repo's group path below the owner, as with GitLab
+	// subgroups. It is placed in front of {name}, so each group becomes a
+	// subdirectory.
+	Namespace string
+}
+
+func (l Layout) template() string {
+	if l.Template == "" {
+		return DefaultTemplate
+	}
+	return l.Template
+}
+
+func (l Layout) Validate() error {
+	tmpl := l.template()
+	for _, match := range placeholder.FindAllStringSubmatch(tmpl, -1) {
+		if !knownPlaceholders[match[1]] {
+			return fmt.Errorf("layout %q: unknown placeholder {%s}", tmpl, match[1])
+		}
+	}
+	if !l.Uses("name") {
+		return fmt.Errorf("layout %q must contain {name}", tmpl)
+	}
+	if l.Uses("root") && l.Root == "" {
+		return fmt.Errorf("layout %q uses {root} but no root is set", tmpl)
+	}
+	return nil
+}
+
+// Uses reports whether the template contains the placeholder, e.g. "topic".
+func (l Layout) Uses(name string) bool {
+	return strings.Contains(l.template(), "{"+name+"}")
+}
+
+// Path returns the checkout directory for a repo.
+func (l Layout) Path(v Vars) (string, error) {
+	return l.expand(l.template(), v)
+}
+
+// Base returns the directory holding an account's checkouts: the template
+// up to the first repo-specific segment. Nested is true when checkouts sit
+// more than one level below it, as with {root}/{owner}/{topic}/{name}.
+func (l Layout) Base(v Vars) (base string, nested bool, err error) {
+	segments := strings.Split(l.template(), "/")
+	for i, segment := range segments {
+		if strings.Contains(segment, "{name}") || strings.Contains(segment, "{topic}") {
+			base, err = l.expand(strings.Join(segments[:i], "/"), v)
+			return base, len(segments)-i > 1, err
+		}
+	}
+	return "", false, fmt.Errorf("layout %q must contain {name}", l.template())
+}
+
+func (l Layout) expand(tmpl string, v Vars) (string, error) {
+	topic := NoTopic
+	if len(v.Topics) > 0 {
+		topic = v.Topics[0]
+	}
+	values := map[string]string{
+		"dir":   v.Dir,
+		"root":  l.Root,
+		"host":  v.Host,
+		"owner": v.Owner,
+		"name":  path.Join(v.Namespace, v.Name),
+		"topic": topic,
+	}
+
+	var missing string
+	expanded := placeholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		key := match[1 : len(match)-1]
+		value := values[key]
+		if value == "" && missing == "" {
+			missing = key
+		}
+		if l.Lowercase && key != "dir" && key != "root" {
+			value = strings.ToLower(value)
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("layout %q: no value for {%s}", tmpl, missing)
+	}
+	return filepath.Clean(expanded), nil
+}
+
+// Target is a repo and the directory it would be checked out into.
+type Target struct {
+	Repo string // owner/name
+	Path string
+}
+
+// Collision is a directory claimed by more than one repo.
+type Collision struct {
+	Path  string
+	Repos []string
+}
+
+func (c Collision) String() string {
+	return fmt.Sprintf("%s is claimed by %s", c.Path, strings.Join(c.Repos, ", "))
+}
+
+// Collisions finds directories that two different repos map to. Paths and
+// repo names are compared case-insensitively, since checkouts often live on
+// case-insensitive filesystems.
+func Collisions(targets []Target) []Collision {
+	type claim struct {
+		path  string
+		repos []string
+		seen  map[string]bool
+	}
+	claims := map[string]*claim{}
+	var order []string
+
+	for _, target := range targets {
+		key := strings.ToLower(filepath.Clean(target.Path))
+		c, ok := claims[key]
+		if !ok {
+			c = &claim{path: target.Path, seen: map[string]bool{}}
+			claims[key] = c
+			order = append(order, key)
+		}
+		repoKey := strings.ToLower(target.Repo)
+		if !c.seen[repoKey] {
+			c.seen[repoKey] = true
+			c.repos = append(c.repos, target.Repo)
+		}
+	}
+
+	var collisions []Collision
+	for _, key := range order {
+		c := claims[key]
+		if len(c.repos) > 1 {
+			sort.Strings(c.repos)
+			collisions = append(collisions, Collision{Path: c.path, Repos: c.repos})
+		}
+	}
+	return collisions
+}
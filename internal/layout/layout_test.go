@@ -0,0 +1,135 @@
+package layout
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPath_Templates(t *testing.T) {
+	vars := Vars{Dir: "/code/myorg", Host: "github.com", Owner: "MyOrg", Name: "API", Topics: []string{"team-payments", "go"}}
+	tests := []struct {
+		layout   Layout
+		expected string
+	}{
+		{Layout{}, "/code/myorg/API"},
+		{Layout{Template: "{root}/{host}/{owner}/{name}", Root: "/src"}, "/src/github.com/MyOrg/API"},
+		{Layout{Template: "{root}/{owner}/{topic}/{name}", Root: "/src", Lowercase: true}, "/src/myorg/team-payments/api"},
+		{Layout{Template: "{dir}/{name}", Lowercase: true}, "/code/myorg/api"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.layout.Path(vars)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.layout.Template, err)
+			continue
+		}
+		if got != filepath.FromSlash(tt.expected) {
+			t.Errorf("%q: expected %q, got %q", tt.layout.Template, tt.expected, got)
+		}
+	}
+}
+
+func TestPath_LowercaseLeavesDirAlone(t *testing.T) {
+	l := Layout{Lowercase: true}
+	got, err := l.Path(Vars{Dir: "/Code/MyOrg", Name: "Repo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/Code/MyOrg/repo" {
+		t.Errorf("expected /Code/MyOrg/repo, got %q", got)
+	}
+}
+
+func TestPath_UntaggedTopic(t *testing.T) {
+	l := Layout{Template: "{root}/{owner}/{topic}/{name}", Root: "/src"}
+	got, err := l.Path(Vars{Owner: "me", Name: "repo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/src/me/"+NoTopic+"/repo" {
+		t.Errorf("unexpected path %q", got)
+	}
+}
+
+func TestPath_NamespaceNestsName(t *testing.T) {
+	l := Layout{Template: "{root}/{host}/{owner}/{name}", Root: "/src"}
+	got, err := l.Path(Vars{Host: "gitlab.example.com", Owner: "acme", Namespace: "platform/backend", Name: "api"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := filepath.FromSlash("/src/gitlab.example.com/acme/platform/backend/api"); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestPath_MissingValue(t *testing.T) {
+	l := Layout{Template: "{dir}/{name}"}
+	if _, err := l.Path(Vars{Name: "repo"}); err == nil {
+		t.Fatal("expected error for empty {dir}, got nil")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []Layout{
+		{},
+		{Template: "{root}/{host}/{owner}/{name}", Root: "/src"},
+	}
+	for _, l := range valid {
+		if err := l.Validate(); err != nil {
+			t.Errorf("%q: unexpected error: %v", l.Template, err)
+		}
+	}
+
+	invalid := []Layout{
+		{Template: "{dir}/{owner}"},
+		{Template: "{dir}/{repo}/{name}"},
+		{Template: "{root}/{name}"},
+	}
+	for _, l := range invalid {
+		if err := l.Validate(); err == nil {
+			t.Errorf("%q: expected error, got nil", l.Template)
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	vars := Vars{Dir: "/code/me", Host: "github.com", Owner: "Me"}
+	tests := []struct {
+		layout         Layout
+		expectedBase   string
+		expectedNested bool
+	}{
+		{Layout{}, "/code/me", false},
+		{Layout{Template: "{root}/{host}/{owner}/{name}", Root: "/src", Lowercase: true}, "/src/github.com/me", false},
+		{Layout{Template: "{root}/{owner}/{topic}/{name}", Root: "/src"}, "/src/Me", true},
+	}
+
+	for _, tt := range tests {
+		base, nested, err := tt.layout.Base(vars)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.layout.Template, err)
+			continue
+		}
+		if base != tt.expectedBase || nested != tt.expectedNested {
+			t.Errorf("%q: expected (%q, %v), got (%q, %v)", tt.layout.Template, tt.expectedBase, tt.expectedNested, base, nested)
+		}
+	}
+}
+
+func TestCollisions(t *testing.T) {
+	targets := []Target{
+		{Repo: "alice/utils", Path: "/src/utils"},
+		{Repo: "bob/utils", Path: "/src/Utils"},
+		{Repo: "alice/api", Path: "/src/api"},
+		{Repo: "alice/api", Path: "/src/api"},
+	}
+
+	collisions := Collisions(targets)
+
+	if len(collisions) != 1 {
+		t.Fatalf("expected 1 collision, got %d: %v", len(collisions), collisions)
+	}
+	if len(collisions[0].Repos) != 2 || collisions[0].Repos[0] != "alice/utils" || collisions[0].Repos[1] != "bob/utils" {
+		t.Errorf("unexpected collision %v", collisions[0])
+	}
+}
@@ -0,0 +1,122 @@
+// Package manifest reads a static list of repos from a YAML or JSON file, for
+// repos on plain git servers that have no API to list them from.
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is one repo in a manifest. Path is the checkout directory relative
+// to the account dir and defaults to the name; Branch is optional.
+type Entry struct {
+	Name   string `yaml:"name" json:"name"`
+	URL    string `yaml:"url" json:"url"`
+	Path   string `yaml:"path,omitempty" json:"path,omitempty"`
+	Branch string `yaml:"branch,omitempty" json:"branch,omitempty"`
+}
+
+// CheckoutPath returns the entry's path, defaulting to its name.
+func (e Entry) CheckoutPath() string {
+	if e.Path == "" {
+		return e.Name
+	}
+	return e.Path
+}
+
+type Manifest struct {
+	Repos []Entry `yaml:"repos" json:"repos"`
+}
+
+// Parse reads a manifest. JSON is accepted too, since it is valid YAML.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *Manifest) Validate() error {
+	names := map[string]bool{}
+	paths := map[string]bool{}
+	for i, entry := range m.Repos {
+		if entry.Name == "" {
+			return fmt.Errorf("manifest repo %d: name is required", i+1)
+		}
+		if entry.URL == "" {
+			return fmt.Errorf("manifest repo %d (%s): url is required", i+1, entry.Name)
+		}
+
+		checkoutPath := entry.CheckoutPath()
+		if path.IsAbs(checkoutPath) || checkoutPath != path.Clean(checkoutPath) || strings.HasPrefix(checkoutPath, "..") {
+			return fmt.Errorf("manifest repo %d (%s): path %q must be a clean relative path", i+1, entry.Name, checkoutPath)
+		}
+
+		name := strings.ToLower(entry.Name)
+		if names[name] {
+			return fmt.Errorf("manifest repo %d (%s): duplicate name", i+1, entry.Name)
+		}
+		names[name] = true
+
+		key := strings.ToLower(checkoutPath)
+		if paths[key] {
+			return fmt.Errorf("manifest repo %d (%s): path %q is used twice", i+1, entry.Name, checkoutPath)
+		}
+		paths[key] = true
+	}
+	return nil
+}
+
+// IsURL reports whether a manifest source is fetched over HTTP rather than
+// read from disk.
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// Load reads a manifest from a file or an http(s) URL. A token, if given, is
+// sent as a bearer token when fetching a URL.
+func Load(source, token string) (*Manifest, error) {
+	var data []byte
+	var err error
+	if IsURL(source) {
+		data, err = fetch(source, token)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	return Parse(data)
+}
+
+func fetch(url, token string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "gitall-cli")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
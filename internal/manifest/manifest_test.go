@@ -0,0 +1,104 @@
+package manifest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse_YAML(t *testing.T) {
+	m, err := Parse([]byte(`
+repos:
+  - name: tools
+    url: git@git.example.com:infra/tools.git
+    path: infra/tools
+    branch: stable
+  - name: notes
+    url: ssh://git@files.example.com/srv/git/notes.git
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m.Repos) != 2 {
+		t.Fatalf("expected 2 repos, got %d", len(m.Repos))
+	}
+	if m.Repos[0].CheckoutPath() != "infra/tools" || m.Repos[0].Branch != "stable" {
+		t.Errorf("unexpected first entry %+v", m.Repos[0])
+	}
+	if m.Repos[1].CheckoutPath() != "notes" {
+		t.Errorf("expected path to default to the name, got %q", m.Repos[1].CheckoutPath())
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	m, err := Parse([]byte(`{"repos":[{"name":"tools","url":"git@git.example.com:infra/tools.git"}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Repos) != 1 || m.Repos[0].Name != "tools" {
+		t.Errorf("unexpected manifest %+v", m)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing url":    `{"repos":[{"name":"tools"}]}`,
+		"missing name":   `{"repos":[{"url":"git@x:y.git"}]}`,
+		"duplicate name": `{"repos":[{"name":"a","url":"u1"},{"name":"A","url":"u2","path":"b"}]}`,
+		"shared path":    `{"repos":[{"name":"a","url":"u1"},{"name":"b","url":"u2","path":"a"}]}`,
+		"escaping path":  `{"repos":[{"name":"a","url":"u1","path":"../a"}]}`,
+		"absolute path":  `{"repos":[{"name":"a","url":"u1","path":"/srv/a"}]}`,
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspace.yaml")
+	if err := os.WriteFile(path, []byte("repos:\n  - name: tools\n    url: git@git.example.com:tools.git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Repos) != 1 {
+		t.Errorf("expected 1 repo, got %d", len(m.Repos))
+	}
+}
+
+func TestLoad_URLSendsToken(t *testing.T) {
+	var receivedAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAuth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"repos":[{"name":"tools","url":"git@git.example.com:tools.git"}]}`)
+	}))
+	defer server.Close()
+
+	m, err := Load(server.URL+"/workspace.json", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Repos) != 1 || receivedAuth != "Bearer secret" {
+		t.Errorf("unexpected manifest %+v or auth %q", m, receivedAuth)
+	}
+}
+
+func TestLoad_URLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := Load(server.URL+"/missing.yaml", ""); err == nil {
+		t.Fatal("expected error for 404, got nil")
+	}
+}
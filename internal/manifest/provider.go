@@ -0,0 +1,113 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/boycook/gitall/internal/git"
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func init() {
+	provider.Register("manifest", newProvider)
+}
+
+// manifestProvider serves the repos listed in a manifest. Every repo carries
+// its own clone URL, so the provider has no single host and ignores the
+// protocol.
+type manifestProvider struct {
+	source  string
+	account provider.Account
+	opts    provider.Options
+
+	once     sync.Once
+	manifest *Manifest
+	err      error
+}
+
+func newProvider(account provider.Account, opts provider.Options) (provider.Provider, error) {
+	source := account.Params["manifest"]
+	if source == "" {
+		return nil, fmt.Errorf("manifest accounts need a manifest path or URL")
+	}
+	return &manifestProvider{source: source, account: account, opts: opts}, nil
+}
+
+func (p *manifestProvider) load() (*Manifest, error) {
+	p.once.Do(func() {
+		if p.opts.Offline && IsURL(p.source) {
+			p.err = fmt.Errorf("%s: %w — run again without --offline", p.source, provider.ErrNotCached)
+			return
+		}
+		p.manifest, p.err = Load(p.source, p.account.Token)
+	})
+	return p.manifest, p.err
+}
+
+func (p *manifestProvider) Name() string {
+	return "manifest"
+}
+
+func (p *manifestProvider) Host() string {
+	return ""
+}
+
+// Credential reports the token sent when fetching a manifest URL, which only
+// ever comes from the config.
+func (p *manifestProvider) Credential() (string, string) {
+	if p.account.Token == "" {
+		return "", ""
+	}
+	return p.account.Token, "config"
+}
+
+func (p *manifestProvider) ListRepos(filter provider.Filter) ([]provider.Repo, error) {
+	m, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]provider.Repo, len(m.Repos))
+	for i, entry := range m.Repos {
+		repos[i] = provider.Repo{
+			Name:     entry.Name,
+			FullName: p.account.Owner + "/" + entry.Name,
+			CloneURL: entry.URL,
+			SSHURL:   entry.URL,
+			Owner:    provider.Owner{Login: p.account.Owner},
+			Path:     entry.CheckoutPath(),
+			Branch:   entry.Branch,
+		}
+	}
+	return provider.FilterRepos(repos, filter), nil
+}
+
+func (p *manifestProvider) CloneURL(repo provider.Repo, _ string) string {
+	return repo.CloneURL
+}
+
+// ParseRemote recognises the clone URLs listed in the manifest. The remote
+// is reported as owned by the account, since manifest URLs need not follow
+// any owner/name scheme.
+func (p *manifestProvider) ParseRemote(remoteURL string) (git.Remote, bool) {
+	m, err := p.load()
+	if err != nil {
+		return git.Remote{}, false
+	}
+
+	for _, entry := range m.Repos {
+		if sameURL(entry.URL, remoteURL) {
+			parsed, _ := git.ParseRemoteURL(remoteURL)
+			return git.Remote{Host: parsed.Host, Owner: p.account.Owner, Name: entry.Name}, true
+		}
+	}
+	return git.Remote{}, false
+}
+
+func sameURL(a, b string) bool {
+	normalise := func(u string) string {
+		return strings.TrimSuffix(strings.TrimRight(u, "/"), ".git")
+	}
+	return normalise(a) != "" && strings.EqualFold(normalise(a), normalise(b))
+}
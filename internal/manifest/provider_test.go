@@ -0,0 +1,71 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "workspace.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProvider_ListsManifestRepos(t *testing.T) {
+	path := writeManifest(t, `
+repos:
+  - name: tools
+    url: git@git.example.com:infra/tools.git
+    path: infra/tools
+    branch: stable
+  - name: notes
+    url: ssh://git@files.example.com/srv/git/notes.git
+`)
+	p, err := provider.New("manifest", provider.Account{Owner: "team", Params: map[string]string{"manifest": path}}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repos, err := p.ListRepos(provider.Filter{Pattern: "t*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repos) != 1 {
+		t.Fatalf("expected only tools, got %+v", repos)
+	}
+	if repos[0].Path != "infra/tools" || repos[0].Branch != "stable" {
+		t.Errorf("unexpected repo %+v", repos[0])
+	}
+	if got := p.CloneURL(repos[0], "https"); got != "git@git.example.com:infra/tools.git" {
+		t.Errorf("expected the manifest URL whatever the protocol, got %q", got)
+	}
+}
+
+func TestProvider_ParseRemoteMatchesListedURLs(t *testing.T) {
+	path := writeManifest(t, "repos:\n  - name: notes\n    url: ssh://git@files.example.com/srv/git/notes.git\n")
+	p, err := provider.New("manifest", provider.Account{Owner: "team", Params: map[string]string{"manifest": path}}, provider.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remote, ok := p.ParseRemote("ssh://git@files.example.com/srv/git/notes")
+	if !ok || remote.Owner != "team" || remote.Name != "notes" || remote.Host != "files.example.com" {
+		t.Errorf("expected team/notes on files.example.com, got %+v (ok=%v)", remote, ok)
+	}
+	if _, ok := p.ParseRemote("ssh://git@files.example.com/srv/git/other.git"); ok {
+		t.Error("expected an unlisted URL not to match")
+	}
+}
+
+func TestProvider_RequiresManifest(t *testing.T) {
+	if _, err := provider.New("manifest", provider.Account{Owner: "team"}, provider.Options{}); err == nil {
+		t.Fatal("expected an error without a manifest, got nil")
+	}
+}
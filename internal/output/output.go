@@ -238,3 +238,25 @@ func printJSONSummary(results []git.RepoResult, summary Summary) {
 	data, _ := json.MarshalIndent(out, "", "  ")
 	fmt.Println(string(data))
 }
+
+type ErrorReport struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+func PrintError(report ErrorReport, asJSON bool) {
+	if asJSON {
+		out := struct {
+			Error ErrorReport `json:"error"`
+		}{Error: report}
+		data, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	red.Fprintln(os.Stderr, report.Message)
+	if report.Hint != "" {
+		fmt.Fprintf(os.Stderr, "%s %s\n", yellow.Sprint("hint:"), report.Hint)
+	}
+}
@@ -0,0 +1,11 @@
+// Package all registers every built-in provider. Import it for its side
+// effects wherever providers are looked up by name.
+package all
+
+import (
+	_ "github.com/boycook/gitall/internal/bitbucket"
+	_ "github.com/boycook/gitall/internal/gitea"
+	_ "github.com/boycook/gitall/internal/github"
+	_ "github.com/boycook/gitall/internal/gitlab"
+	_ "github.com/boycook/gitall/internal/manifest"
+)
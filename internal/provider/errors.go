@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Errors shared by providers. Provider-specific API errors wrap one of these
+// so callers can use errors.Is without knowing which forge failed.
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limit exceeded")
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotCached    = errors.New("not in cache")
+)
+
+// APIError describes a failed API request to a forge. Kind is one of the
+// Err* sentinels, or nil for other statuses. The GitHub client has its own
+// richer error type.
+type APIError struct {
+	Forge      string // e.g. "GitLab"
+	Kind       error
+	StatusCode int
+	URL        string
+	Message    string
+	ResetAt    time.Time
+
+	// TokenVars and Scopes fill in the hint: the variables the provider
+	// reads its token from, and the token scopes listing repos needs.
+	TokenVars string // e.g. "GITLAB_TOKEN"
+	Scopes    string // e.g. "read_api"
+}
+
+func (e *APIError) Error() string {
+	var msg string
+	switch {
+	case e.Kind == nil:
+		msg = fmt.Sprintf("returned status %d", e.StatusCode)
+	case e.Kind == ErrRateLimited && !e.ResetAt.IsZero():
+		msg = fmt.Sprintf("%s until %s", e.Kind, e.ResetAt.Local().Format("15:04"))
+	default:
+		msg = e.Kind.Error()
+	}
+
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return e.Forge + " API " + msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// Hint suggests how to fix the failure.
+func (e *APIError) Hint() string {
+	switch e.Kind {
+	case ErrUnauthorized:
+		hint := "the token is invalid or expired — update the account's token"
+		if e.TokenVars != "" {
+			hint += " or " + e.TokenVars
+		}
+		return hint
+	case ErrRateLimited:
+		if !e.ResetAt.IsZero() {
+			return fmt.Sprintf("wait until %s, or use a token for a higher limit", e.ResetAt.Local().Format("15:04"))
+		}
+		return "wait a few minutes, or use a token for a higher limit"
+	case ErrNotFound:
+		return "check the account name — private repos and groups also return 404 when the token lacks access"
+	case ErrForbidden:
+		if e.Scopes != "" {
+			return fmt.Sprintf("the token lacks permission — check its scopes (%s)", e.Scopes)
+		}
+		return "the token lacks permission — check its scopes"
+	}
+	return ""
+}
+
+// KindForStatus maps an HTTP status to the matching Err* sentinel.
+func KindForStatus(status int) error {
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
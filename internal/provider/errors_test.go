@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIError_HintNamesTheForgesToken(t *testing.T) {
+	err := &APIError{Forge: "GitLab", Kind: ErrUnauthorized, StatusCode: 401, TokenVars: "GITLAB_TOKEN", Scopes: "read_api"}
+	if hint := err.Hint(); !strings.Contains(hint, "GITLAB_TOKEN") || strings.Contains(hint, "GITHUB") {
+		t.Errorf("expected a GitLab token hint, got %q", hint)
+	}
+
+	err.Kind = ErrForbidden
+	if hint := err.Hint(); !strings.Contains(hint, "read_api") || strings.Contains(hint, "repo,") {
+		t.Errorf("expected the GitLab scopes, got %q", hint)
+	}
+
+	if hint := (&APIError{Forge: "Gitea", StatusCode: 500}).Hint(); hint != "" {
+		t.Errorf("expected no hint for other statuses, got %q", hint)
+	}
+}
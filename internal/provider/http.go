@@ -0,0 +1,20 @@
+package provider
+
+import "strings"
+
+// NextLink returns the rel="next" URL of an RFC 8288 Link header, or "" on
+// the last page.
+func NextLink(linkHeader string) string {
+	for _, part := range strings.Split(linkHeader, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.Contains(part, `rel="next"`) {
+			continue
+		}
+		start := strings.Index(part, "<")
+		end := strings.Index(part, ">")
+		if start != -1 && end > start {
+			return part[start+1 : end]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+// Package provider defines the interface between gitall and a code forge.
+// A provider lists an account's repos, builds clone URLs and recognises the
+// forge's remote URLs; implementations register themselves by name.
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boycook/gitall/internal/git"
+)
+
+// Default is the provider used when an account does not name one.
+const Default = "github"
+
+type Provider interface {
+	// Name is the name the provider is registered under.
+	Name() string
+	// Host is the git host repos are cloned from, e.g. github.com, or ""
+	// when it differs from repo to repo.
+	Host() string
+	// ListRepos lists the account's repos that match filter.
+	ListRepos(filter Filter) ([]Repo, error)
+	// CloneURL returns the URL to clone repo over "ssh" or "https".
+	CloneURL(repo Repo, protocol string) string
+	// ParseRemote parses a remote URL, reporting false for URLs that do not
+	// belong to the provider's host.
+	ParseRemote(remoteURL string) (git.Remote, bool)
+}
+
+// WikiProvider is implemented by providers that serve repo wikis as
+// separate git repos.
+type WikiProvider interface {
+	WikiURL(repo Repo, protocol string) string
+}
+
+// CredentialProvider is implemented by providers that find their own token
+// rather than using Options.Credentials, so that commands can report it.
+type CredentialProvider interface {
+	// Credential returns the token the provider authenticates with and
+	// where it was found, such as "config" or an environment variable. Both
+	// are empty when there is none.
+	Credential() (token, source string)
+}
+
+// GroupProvider is implemented by providers whose owners can contain nested
+// groups. Their repos may be checked out below subdirectories of the
+// account dir, one per group.
+type GroupProvider interface {
+	NestedGroups() bool
+}
+
+// Account is the forge account a provider lists repos for.
+type Account struct {
+	Owner  string
+	APIURL string // empty for the provider's public service
+	Token  string // from the config; providers may look further
+	Mode   string // provider-specific listing mode
+	Team   string
+	Params map[string]string // provider-specific listing parameters
+}
+
+// Options are CLI-wide settings shared by every provider.
+type Options struct {
+	Offline bool // answer API requests from the response cache only
+	Refresh bool // bypass the response cache
+	Retries int
+	MaxWait time.Duration
+	// Credentials looks up a token for a host when the config has none.
+	// Providers without a host-agnostic credential source may ignore it.
+	Credentials func(configToken, host string) string
+}
+
+type Factory func(account Account, opts Options) (Provider, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+)
+
+// Register makes a provider available under name. It panics if the name is
+// taken, since that can only be a programming error.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic("provider: Register called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// New builds the named provider for an account. An empty name means Default.
+func New(name string, account Account, opts Options) (Provider, error) {
+	if name == "" {
+		name = Default
+	}
+
+	mu.RLock()
+	factory, ok := factories[strings.ToLower(name)]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(account, opts)
+}
+
+// Names returns the registered provider names in order.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/boycook/gitall/internal/git"
+)
+
+type stubProvider struct{ account Account }
+
+func (s stubProvider) Name() string                          { return "stub" }
+func (s stubProvider) Host() string                          { return "forge.example.com" }
+func (s stubProvider) ListRepos(Filter) ([]Repo, error)      { return nil, nil }
+func (s stubProvider) CloneURL(repo Repo, _ string) string   { return repo.Name }
+func (s stubProvider) ParseRemote(string) (git.Remote, bool) { return git.Remote{}, false }
+
+func TestNew_BuildsRegisteredProvider(t *testing.T) {
+	Register("stub", func(account Account, opts Options) (Provider, error) {
+		return stubProvider{account: account}, nil
+	})
+
+	p, err := New("Stub", Account{Owner: "me"}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name() != "stub" || p.(stubProvider).account.Owner != "me" {
+		t.Errorf("unexpected provider %+v", p)
+	}
+}
+
+func TestNew_UnknownProvider(t *testing.T) {
+	if _, err := New("sourceforge", Account{}, Options{}); err == nil {
+		t.Fatal("expected error for unknown provider, got nil")
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	header := `<https://forge.example.com/repos?page=1>; rel="prev", <https://forge.example.com/repos?page=3>; rel="next"`
+	if got := NextLink(header); got != "https://forge.example.com/repos?page=3" {
+		t.Errorf("unexpected next link %q", got)
+	}
+	if got := NextLink(`<https://forge.example.com/repos?page=1>; rel="prev"`); got != "" {
+		t.Errorf("expected no next link on the last page, got %q", got)
+	}
+}
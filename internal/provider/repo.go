@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo is a remote repository as reported by a provider. The JSON tags
+// follow the GitHub REST API, which the GitHub provider decodes directly.
+type Repo struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	FullName      string    `json:"full_name"`
+	Description   string    `json:"description"`
+	CloneURL      string    `json:"clone_url"`
+	SSHURL        string    `json:"ssh_url"`
+	DefaultBranch string    `json:"default_branch"`
+	Topics        []string  `json:"topics"`
+	Language      string    `json:"language"`
+	Visibility    string    `json:"visibility"` // public, private or internal
+	Private       bool      `json:"private"`
+	PushedAt      time.Time `json:"pushed_at"`
+	Size          int       `json:"size"` // in KB
+	Fork          bool      `json:"fork"`
+	Archived      bool      `json:"archived"`
+	HasWiki       bool      `json:"has_wiki"`
+	Owner         Owner     `json:"owner"`
+	Parent        *Repo     `json:"parent,omitempty"` // only set by single-repo lookups
+
+	// Namespace is the path of the group the repo sits in below the
+	// account's owner, e.g. "platform/backend" for acme/platform/backend/api
+	// when listing acme. It is empty on forges without nested groups.
+	Namespace string `json:"-"`
+	// Path and Branch are set by sources that decide the checkout
+	// themselves: a directory relative to the account dir, which overrides
+	// the layout, and the branch to check out.
+	Path   string `json:"-"`
+	Branch string `json:"-"`
+}
+
+type Owner struct {
+	Login string `json:"login"`
+	Type  string `json:"type"` // "User" or "Organization"
+}
+
+// RepoVisibility returns the repo's visibility, falling back to the private
+// flag for servers that do not report the visibility field.
+func (r Repo) RepoVisibility() string {
+	if r.Visibility != "" {
+		return r.Visibility
+	}
+	if r.Private {
+		return "private"
+	}
+	return "public"
+}
+
+// ListedOwner returns the owner of a repo listed for account: the owner the
+// provider reports when it is someone else, as for org repos in a listing of
+// the authenticated user, and otherwise account. Repos in a subgroup of
+// account keep account, with the group path in Namespace.
+func (r Repo) ListedOwner(account string) string {
+	if r.Namespace == "" && r.Owner.Login != "" && !strings.EqualFold(r.Owner.Login, account) {
+		return r.Owner.Login
+	}
+	return account
+}
+
+// HasTopic reports whether the repo is tagged with the topic.
+func (r Repo) HasTopic(topic string) bool {
+	for _, t := range r.Topics {
+		if strings.EqualFold(t, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter selects repos by name and metadata after listing.
+type Filter struct {
+	NoForks    bool
+	NoArchived bool
+	Pattern    string // glob-style name pattern (e.g. "prefix-*")
+
+	Topics      []string  // keep repos tagged with any of these topics
+	Language    string    // primary language, case-insensitive
+	Visibility  string    // all, public, private or internal
+	PushedAfter time.Time // keep repos pushed at or after this time
+	MaxSize     int       // in KB, 0 for no limit
+}
+
+func FilterRepos(repos []Repo, filter Filter) []Repo {
+	var filtered []Repo
+
+	var pattern *regexp.Regexp
+	if filter.Pattern != "" {
+		regexStr := "^" + globToRegex(filter.Pattern) + "$"
+		pattern, _ = regexp.Compile(regexStr)
+	}
+
+	for _, repo := range repos {
+		if filter.NoForks && repo.Fork {
+			continue
+		}
+		if filter.NoArchived && repo.Archived {
+			continue
+		}
+		if pattern != nil && !pattern.MatchString(repo.Name) {
+			continue
+		}
+		if !matchesMetadata(repo, filter) {
+			continue
+		}
+		filtered = append(filtered, repo)
+	}
+
+	return filtered
+}
+
+func matchesMetadata(repo Repo, filter Filter) bool {
+	if len(filter.Topics) > 0 {
+		found := false
+		for _, topic := range filter.Topics {
+			if repo.HasTopic(topic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.Language != "" && !strings.EqualFold(repo.Language, filter.Language) {
+		return false
+	}
+	if filter.Visibility != "" && filter.Visibility != "all" && !strings.EqualFold(repo.RepoVisibility(), filter.Visibility) {
+		return false
+	}
+	if !filter.PushedAfter.IsZero() && repo.PushedAt.Before(filter.PushedAfter) {
+		return false
+	}
+	if filter.MaxSize > 0 && repo.Size > filter.MaxSize {
+		return false
+	}
+	return true
+}
+
+func globToRegex(glob string) string {
+	var result strings.Builder
+	for _, ch := range glob {
+		switch ch {
+		case '*':
+			result.WriteString(".*")
+		case '?':
+			result.WriteString(".")
+		case '.', '(', ')', '+', '|', '^', '$', '[', ']', '{', '}', '\\':
+			result.WriteRune('\\')
+			result.WriteRune(ch)
+		default:
+			result.WriteRune(ch)
+		}
+	}
+	return result.String()
+}
+
+// ParseSince parses a --pushed-since value: a date (2006-01-02), an RFC 3339
+// timestamp, or a period before now such as 30d, 12w, 6m or 1y.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid date or period %q (use 2006-01-02 or e.g. 30d, 12w, 6m, 1y)", s)
+	}
+	switch s[len(s)-1] {
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	case 'y':
+		return now.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date or period %q (use 2006-01-02 or e.g. 30d, 12w, 6m, 1y)", s)
+}
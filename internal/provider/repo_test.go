@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		glob     string
+		input    string
+		expected bool
+	}{
+		{"api-*", "api-gateway", true},
+		{"api-*", "web-frontend", false},
+		{"*-service", "auth-service", true},
+		{"*-service", "auth-controller", false},
+		{"exact", "exact", true},
+		{"exact", "notexact", false},
+	}
+
+	for _, tc := range tests {
+		regexStr := "^" + globToRegex(tc.glob) + "$"
+		re, err := regexp.Compile(regexStr)
+		if err != nil {
+			t.Fatalf("invalid regex %q from glob %q: %v", regexStr, tc.glob, err)
+		}
+		result := re.MatchString(tc.input)
+		if result != tc.expected {
+			t.Errorf("glob %q against %q: expected %v, got %v", tc.glob, tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestFilterRepos_ByTopicAndPushedDate(t *testing.T) {
+	cutoff := time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)
+	repos := []Repo{
+		{Name: "recent", Topics: []string{"team-payments"}, PushedAt: cutoff.AddDate(0, 1, 0)},
+		{Name: "stale", Topics: []string{"team-payments"}, PushedAt: cutoff.AddDate(0, -1, 0)},
+		{Name: "other-team", Topics: []string{"team-search"}, PushedAt: cutoff.AddDate(0, 1, 0)},
+	}
+
+	filtered := FilterRepos(repos, Filter{Topics: []string{"team-payments"}, PushedAfter: cutoff})
+
+	if len(filtered) != 1 || filtered[0].Name != "recent" {
+		t.Errorf("expected only recent, got %+v", filtered)
+	}
+}
+
+func TestFilterRepos_ByLanguageVisibilityAndSize(t *testing.T) {
+	repos := []Repo{
+		{Name: "small-go", Language: "Go", Private: true, Size: 100},
+		{Name: "big-go", Language: "Go", Private: true, Size: 900000},
+		{Name: "public-go", Language: "Go", Size: 100},
+		{Name: "small-js", Language: "JavaScript", Private: true, Size: 100},
+	}
+
+	filtered := FilterRepos(repos, Filter{Language: "go", Visibility: "private", MaxSize: 1000})
+
+	if len(filtered) != 1 || filtered[0].Name != "small-go" {
+		t.Errorf("expected only small-go, got %+v", filtered)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"", time.Time{}},
+		{"2025-06-01", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"2025-06-01T10:00:00Z", time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)},
+		{"30d", now.AddDate(0, 0, -30)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"6m", now.AddDate(0, -6, 0)},
+		{"1y", now.AddDate(-1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSince(tt.input, now)
+		if err != nil {
+			t.Errorf("ParseSince(%q): unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("ParseSince(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+
+	for _, input := range []string{"yesterday", "1x", "d"} {
+		if _, err := ParseSince(input, now); err == nil {
+			t.Errorf("ParseSince(%q): expected error", input)
+		}
+	}
+}
+
+func TestFilterRepos_ExcludesForksArchivedAndNames(t *testing.T) {
+	repos := []Repo{
+		{Name: "api-gateway"},
+		{Name: "api-fork", Fork: true},
+		{Name: "api-old", Archived: true},
+		{Name: "web-frontend"},
+	}
+
+	filtered := FilterRepos(repos, Filter{NoForks: true, NoArchived: true, Pattern: "api-*"})
+
+	if len(filtered) != 1 || filtered[0].Name != "api-gateway" {
+		t.Errorf("expected only api-gateway, got %+v", filtered)
+	}
+}
@@ -0,0 +1,71 @@
+package reconcile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+type Move struct {
+	ID       int64
+	Path     string
+	OldOwner string
+	OldName  string
+	NewOwner string
+	NewName  string
+}
+
+func (m Move) Renamed() bool {
+	return !strings.EqualFold(m.OldName, m.NewName)
+}
+
+func (m Move) Transferred() bool {
+	return !strings.EqualFold(m.OldOwner, m.NewOwner)
+}
+
+func (m Move) String() string {
+	return fmt.Sprintf("%s/%s -> %s/%s", m.OldOwner, m.OldName, m.NewOwner, m.NewName)
+}
+
+// DetectMove compares the owner and name a checkout points at with the
+// repo's current identity upstream. It reports false when nothing changed.
+func DetectMove(path, oldOwner, oldName string, current provider.Repo) (Move, bool) {
+	newOwner := current.Owner.Login
+	if newOwner == "" {
+		if owner, _, ok := strings.Cut(current.FullName, "/"); ok {
+			newOwner = owner
+		}
+	}
+
+	move := Move{
+		ID:       current.ID,
+		Path:     path,
+		OldOwner: oldOwner,
+		OldName:  oldName,
+		NewOwner: newOwner,
+		NewName:  current.Name,
+	}
+	if move.NewOwner == "" || move.NewName == "" {
+		return move, false
+	}
+	return move, move.Renamed() || move.Transferred()
+}
+
+// RenameDir renames a checkout directory to newName alongside its current
+// location, refusing to overwrite an existing directory.
+func RenameDir(repoPath, newName string) (string, error) {
+	target := filepath.Join(filepath.Dir(repoPath), newName)
+	if target == repoPath {
+		return repoPath, nil
+	}
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	}
+	if err := os.Rename(repoPath, target); err != nil {
+		return "", fmt.Errorf("renaming directory: %w", err)
+	}
+	return target, nil
+}
@@ -0,0 +1,83 @@
+package reconcile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func TestDetectMove_Rename(t *testing.T) {
+	current := provider.Repo{ID: 42, Name: "new-name", Owner: provider.Owner{Login: "BoyCook"}}
+
+	move, moved := DetectMove("/code/old-name", "BoyCook", "old-name", current)
+	if !moved {
+		t.Fatal("expected move to be detected")
+	}
+
+	if !move.Renamed() {
+		t.Error("expected rename")
+	}
+	if move.Transferred() {
+		t.Error("expected no transfer")
+	}
+
+	expected := "BoyCook/old-name -> BoyCook/new-name"
+	if move.String() != expected {
+		t.Errorf("expected %q, got %q", expected, move.String())
+	}
+}
+
+func TestDetectMove_TransferFromFullName(t *testing.T) {
+	current := provider.Repo{ID: 42, Name: "tool", FullName: "NewOrg/tool"}
+
+	move, moved := DetectMove("/code/tool", "OldOrg", "tool", current)
+	if !moved {
+		t.Fatal("expected move to be detected")
+	}
+
+	if !move.Transferred() {
+		t.Error("expected transfer")
+	}
+
+	expectedOwner := "NewOrg"
+	if move.NewOwner != expectedOwner {
+		t.Errorf("expected owner %q, got %q", expectedOwner, move.NewOwner)
+	}
+}
+
+func TestDetectMove_UnchangedIgnoresCase(t *testing.T) {
+	current := provider.Repo{Name: "GitAll", Owner: provider.Owner{Login: "BoyCook"}}
+
+	if _, moved := DetectMove("/code/gitall", "boycook", "gitall", current); moved {
+		t.Error("expected no move for case-only difference")
+	}
+}
+
+func TestRenameDir_RenamesAlongside(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "old-name")
+	os.MkdirAll(repoPath, 0o755)
+
+	target, err := RenameDir(repoPath, "new-name")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := filepath.Join(root, "new-name")
+	if target != expected {
+		t.Errorf("expected %q, got %q", expected, target)
+	}
+}
+
+func TestRenameDir_RefusesToOverwrite(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "old-name")
+	os.MkdirAll(repoPath, 0o755)
+	os.MkdirAll(filepath.Join(root, "new-name"), 0o755)
+
+	if _, err := RenameDir(repoPath, "new-name"); err == nil {
+		t.Fatal("expected error when target exists, got nil")
+	}
+}
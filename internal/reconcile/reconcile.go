@@ -0,0 +1,121 @@
+package reconcile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+type Policy string
+
+const (
+	PolicyKeep    Policy = "keep"
+	PolicyArchive Policy = "archive"
+	PolicyRemove  Policy = "remove"
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch Policy(strings.ToLower(s)) {
+	case "", PolicyKeep:
+		return PolicyKeep, nil
+	case PolicyArchive:
+		return PolicyArchive, nil
+	case PolicyRemove:
+		return PolicyRemove, nil
+	default:
+		return "", fmt.Errorf("invalid policy %q (must be keep, archive or remove)", s)
+	}
+}
+
+type Local struct {
+	Name string
+	Path string
+	ID   int64 // the repo ID stored in the config, or 0
+	// Owned is set when the checkout's remote is on the account's host and
+	// owner. Other checkouts under the account dir, such as clones of
+	// someone else's repo, are never reported as deleted.
+	Owned bool
+}
+
+type Stale struct {
+	Local  Local
+	Remote *provider.Repo // nil when the repo was deleted upstream
+}
+
+type Plan struct {
+	Missing  []provider.Repo
+	Deleted  []Stale
+	Archived []Stale
+	Unknown  []Local // not listed, but not known to be the account's either
+	InSync   int
+}
+
+// Compare matches remote repos against local checkouts by stored repo ID,
+// then by case-insensitive name. Remote repos with no checkout are missing,
+// and checkouts of archived repos are flagged. A checkout with no remote
+// repo was deleted upstream if it is owned by the account or has a stored
+// ID the listing lacks; otherwise nothing is known about it.
+func Compare(remote []provider.Repo, local []Local) Plan {
+	var plan Plan
+
+	remoteByName := make(map[string]provider.Repo, len(remote))
+	remoteByID := make(map[int64]provider.Repo, len(remote))
+	for _, repo := range remote {
+		remoteByName[strings.ToLower(repo.Name)] = repo
+		if repo.ID != 0 {
+			remoteByID[repo.ID] = repo
+		}
+	}
+
+	localNames := make(map[string]bool, len(local))
+	for _, l := range local {
+		key := strings.ToLower(l.Name)
+		localNames[key] = true
+
+		repo, ok := remoteByID[l.ID]
+		if ok {
+			localNames[strings.ToLower(repo.Name)] = true
+		} else {
+			repo, ok = remoteByName[key]
+		}
+		switch {
+		case !ok && (l.Owned || l.ID != 0):
+			plan.Deleted = append(plan.Deleted, Stale{Local: l})
+		case !ok:
+			plan.Unknown = append(plan.Unknown, l)
+		case repo.Archived:
+			plan.Archived = append(plan.Archived, Stale{Local: l, Remote: &repo})
+		default:
+			plan.InSync++
+		}
+	}
+
+	for _, repo := range remote {
+		if !localNames[strings.ToLower(repo.Name)] {
+			plan.Missing = append(plan.Missing, repo)
+		}
+	}
+
+	return plan
+}
+
+// MoveToArchive moves a checkout into archiveDir, keeping its directory name.
+// It refuses to overwrite an existing directory.
+func MoveToArchive(repoPath, archiveDir string) (string, error) {
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating archive directory: %w", err)
+	}
+
+	target := filepath.Join(archiveDir, filepath.Base(repoPath))
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	}
+
+	if err := os.Rename(repoPath, target); err != nil {
+		return "", fmt.Errorf("moving to archive: %w", err)
+	}
+	return target, nil
+}
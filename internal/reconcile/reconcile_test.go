@@ -0,0 +1,141 @@
+package reconcile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boycook/gitall/internal/provider"
+)
+
+func TestCompare_ClassifiesRepos(t *testing.T) {
+	remote := []provider.Repo{
+		{Name: "api"},
+		{Name: "Web"},
+		{Name: "legacy", Archived: true},
+		{Name: "new-service"},
+	}
+	local := []Local{
+		{Name: "api", Path: "/code/api"},
+		{Name: "web", Path: "/code/web"},
+		{Name: "legacy", Path: "/code/legacy"},
+		{Name: "removed", Path: "/code/removed", Owned: true},
+	}
+
+	plan := Compare(remote, local)
+
+	expectedInSync := 2
+	if plan.InSync != expectedInSync {
+		t.Errorf("expected %d in sync, got %d", expectedInSync, plan.InSync)
+	}
+
+	expectedMissing := 1
+	if len(plan.Missing) != expectedMissing {
+		t.Fatalf("expected %d missing, got %d", expectedMissing, len(plan.Missing))
+	}
+	if plan.Missing[0].Name != "new-service" {
+		t.Errorf("expected missing %q, got %q", "new-service", plan.Missing[0].Name)
+	}
+
+	expectedDeleted := 1
+	if len(plan.Deleted) != expectedDeleted {
+		t.Fatalf("expected %d deleted, got %d", expectedDeleted, len(plan.Deleted))
+	}
+	if plan.Deleted[0].Local.Path != "/code/removed" {
+		t.Errorf("expected deleted path %q, got %q", "/code/removed", plan.Deleted[0].Local.Path)
+	}
+
+	expectedArchived := 1
+	if len(plan.Archived) != expectedArchived {
+		t.Fatalf("expected %d archived, got %d", expectedArchived, len(plan.Archived))
+	}
+	if plan.Archived[0].Remote == nil || plan.Archived[0].Remote.Name != "legacy" {
+		t.Errorf("expected archived remote %q, got %+v", "legacy", plan.Archived[0].Remote)
+	}
+}
+
+func TestCompare_OnlyOwnedCheckoutsAreDeleted(t *testing.T) {
+	remote := []provider.Repo{{ID: 1, Name: "api-v2"}}
+	local := []Local{
+		{Name: "api", Path: "/code/api", ID: 1},
+		{Name: "gone", Path: "/code/gone", ID: 2},
+		{Name: "upstream-fork", Path: "/code/upstream-fork"},
+	}
+
+	plan := Compare(remote, local)
+
+	if plan.InSync != 1 || len(plan.Missing) != 0 {
+		t.Errorf("expected the renamed repo to match by ID, got %+v", plan)
+	}
+	if len(plan.Deleted) != 1 || plan.Deleted[0].Local.Name != "gone" {
+		t.Errorf("expected only the checkout with a stored ID to be deleted, got %+v", plan.Deleted)
+	}
+	if len(plan.Unknown) != 1 || plan.Unknown[0].Name != "upstream-fork" {
+		t.Errorf("expected the checkout of another owner's repo to be unknown, got %+v", plan.Unknown)
+	}
+}
+
+func TestCompare_EmptyLocal(t *testing.T) {
+	plan := Compare([]provider.Repo{{Name: "a"}, {Name: "b"}}, nil)
+
+	expectedMissing := 2
+	if len(plan.Missing) != expectedMissing {
+		t.Errorf("expected %d missing, got %d", expectedMissing, len(plan.Missing))
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Policy
+		wantErr  bool
+	}{
+		{"", PolicyKeep, false},
+		{"keep", PolicyKeep, false},
+		{"Archive", PolicyArchive, false},
+		{"remove", PolicyRemove, false},
+		{"delete", "", true},
+	}
+
+	for _, tc := range tests {
+		policy, err := ParsePolicy(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("ParsePolicy(%q): unexpected error state: %v", tc.input, err)
+		}
+		if policy != tc.expected {
+			t.Errorf("ParsePolicy(%q): expected %q, got %q", tc.input, tc.expected, policy)
+		}
+	}
+}
+
+func TestMoveToArchive_MovesDirectory(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "old-repo")
+	os.MkdirAll(repoPath, 0o755)
+	archiveDir := filepath.Join(root, ".archived")
+
+	target, err := MoveToArchive(repoPath, archiveDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedTarget := filepath.Join(archiveDir, "old-repo")
+	if target != expectedTarget {
+		t.Errorf("expected target %q, got %q", expectedTarget, target)
+	}
+	if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
+		t.Error("expected original directory to be gone")
+	}
+}
+
+func TestMoveToArchive_RefusesToOverwrite(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "old-repo")
+	os.MkdirAll(repoPath, 0o755)
+	archiveDir := filepath.Join(root, ".archived")
+	os.MkdirAll(filepath.Join(archiveDir, "old-repo"), 0o755)
+
+	if _, err := MoveToArchive(repoPath, archiveDir); err == nil {
+		t.Fatal("expected error when archive target exists, got nil")
+	}
+}